@@ -0,0 +1,276 @@
+package gox
+
+import (
+	"bufio"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// generatedMarker gox 生成文件的文件头前缀
+const generatedMarker = "// Code generated by gox"
+
+// Clean 移除 gox 生成的文件
+// 只删除增量编译清单中记录的目标文件以及带有 gox 生成文件头的生成文件（默认 _gen.go），
+// 其他工具（stringer、mockgen 等）生成的同名后缀文件会被保留；overlay 模式下同时删除 overlay 文件
+func (c *Compiler) Clean() error {
+	if err := c.prepare(); err != nil {
+		return err
+	}
+	if err := c.removeGeneratedFiles(); err != nil {
+		return err
+	}
+
+	if c.overlayMode() {
+		if err := os.Remove(c.Overlay); err == nil {
+			c.reporter().FileRemoved(c.Overlay)
+		} else if !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeGeneratedFiles 移除输出目录下所有由 gox 生成的文件以及清单文件
+// 只删除对应源文件会被目录编译选中的生成文件，见 sourceSelector
+func (c *Compiler) removeGeneratedFiles() error {
+	rep := c.reporter()
+	root := c.outputRoot()
+
+	selects, err := c.sourceSelector()
+	if err != nil {
+		return err
+	}
+
+	m, err := readManifest(root)
+	if err != nil {
+		return err
+	}
+	// 生成文件路径 -> 源文件路径
+	recorded := make(map[string]string, len(m.Files))
+	for key, entry := range m.Files {
+		recorded[m.abs(entry.Output)] = m.abs(key)
+	}
+
+	var removed []string
+	err = c.walkOutputs(root, func(path string) error {
+		source, ok := recorded[path]
+		if !ok {
+			var generated bool
+			if source, generated = generatedSource(path); !generated {
+				return nil
+			}
+		}
+		if source != "" && !selects(source) {
+			return nil
+		}
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		removed = append(removed, path)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// 清单中记录但不在输出根目录下的文件（例如 mirror 布局之外的旧输出）
+	var outside []string
+	for path, source := range recorded {
+		if !selects(source) {
+			continue
+		}
+		if _, err := os.Stat(path); err == nil {
+			outside = append(outside, path)
+		}
+	}
+	sort.Strings(outside)
+	for _, path := range outside {
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		removed = append(removed, path)
+	}
+
+	if err := os.Remove(m.path); err == nil {
+		removed = append(removed, m.path)
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	for _, path := range removed {
+		rep.FileRemoved(path)
+	}
+	return nil
+}
+
+// pruneOrphans 删除源文件已不存在的生成文件
+// 依据增量编译清单中的记录，以及输出目录中 gox 生成文件头记录的源文件路径；
+// 只处理源文件路径会被目录编译选中的生成文件，见 sourceSelector
+func (c *Compiler) pruneOrphans() error {
+	rep := c.reporter()
+
+	selects, err := c.sourceSelector()
+	if err != nil {
+		return err
+	}
+
+	// 源文件路径 -> 生成文件路径
+	orphans := make(map[string]string)
+
+	if m := c.manifest; m != nil {
+		m.mu.Lock()
+		for key, entry := range m.Files {
+			if !selects(m.abs(key)) {
+				continue
+			}
+			if _, err := os.Stat(m.abs(key)); os.IsNotExist(err) {
+				orphans[m.abs(key)] = m.abs(entry.Output)
+			}
+		}
+		m.mu.Unlock()
+	}
+
+	err = c.walkOutputs(c.outputRoot(), func(path string) error {
+		source, ok := generatedSource(path)
+		if !ok || source == "" || !selects(source) {
+			return nil
+		}
+		if _, err := os.Stat(source); os.IsNotExist(err) {
+			orphans[source] = path
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	sources := make([]string, 0, len(orphans))
+	for src := range orphans {
+		sources = append(sources, src)
+	}
+	sort.Strings(sources)
+
+	for _, src := range sources {
+		output := orphans[src]
+		if c.manifest != nil {
+			c.manifest.forget(src)
+		}
+		if err := os.Remove(output); err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return err
+		}
+		rep.FileRemoved(output)
+	}
+	return nil
+}
+
+// sourceSelector 返回判断源文件是否属于目录编译范围的函数：位于 SrcPath 下，
+// 没有被默认跳过的目录、Exclude、.goxignore 或 Include 排除，非递归模式下只包括 SrcPath 目录本身
+// 清理时据此判断生成文件是否由本次编译管理，源文件已被删除时同样按路径判断
+func (c *Compiler) sourceSelector() (func(source string) bool, error) {
+	root := c.srcRoot()
+	filter, err := c.newSourceFilter(root)
+	if err != nil {
+		return nil, err
+	}
+	return func(source string) bool {
+		return !c.skipSubdir(root, filepath.Dir(source)) && filter.selects(source)
+	}, nil
+}
+
+// walkOutputs 遍历 root 下带有输出后缀的文件，跳过目录编译同样会跳过的目录
+// （隐藏目录、vendor 等默认跳过的目录、Exclude 和 .goxignore 排除的目录，非递归模式下的子目录）
+func (c *Compiler) walkOutputs(root string, fn func(path string) error) error {
+	filter, err := c.newSourceFilter(root)
+	if err != nil {
+		return err
+	}
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if c.skipSubdir(root, path) || filter.skip(path, true) {
+				return filepath.SkipDir
+			}
+			return filter.enterDir(path)
+		}
+		if !strings.HasSuffix(path, c.outputSuffix()) {
+			return nil
+		}
+		return fn(path)
+	})
+}
+
+// isGoxGenerated 检查文件是否以 gox 生成文件头开始
+func isGoxGenerated(path string) bool {
+	_, ok := generatedSource(path)
+	return ok
+}
+
+// generatedSource 读取 gox 生成文件头，返回其中记录的源文件绝对路径
+// 文件头格式为 "// Code generated by gox from <source>. DO NOT EDIT."，source 相对文件所在目录
+func generatedSource(path string) (string, bool) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", false
+	}
+	defer f.Close()
+
+	line, err := bufio.NewReader(f).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", false
+	}
+	if !strings.HasPrefix(line, generatedMarker) {
+		return "", false
+	}
+
+	rest := strings.TrimPrefix(strings.TrimSpace(line), generatedMarker+" from ")
+	source, found := strings.CutSuffix(rest, ". DO NOT EDIT.")
+	if !found || source == "" {
+		return "", true
+	}
+	source = filepath.FromSlash(source)
+	if !filepath.IsAbs(source) {
+		source = filepath.Join(filepath.Dir(path), source)
+	}
+	return source, true
+}
+
+// relativeSourceName 返回源文件相对目标文件所在目录的路径，用于生成文件头
+func relativeSourceName(goxPath, goPath string) string {
+	rel, err := filepath.Rel(filepath.Dir(goPath), goxPath)
+	if err != nil {
+		return filepath.Base(goxPath)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// ownsOutput 检查目标文件是否可以由 gox 覆盖：不存在、带有 gox 文件头或记录在清单中
+func (c *Compiler) ownsOutput(goPath string) bool {
+	if _, err := os.Stat(goPath); os.IsNotExist(err) {
+		return true
+	}
+	if isGoxGenerated(goPath) {
+		return true
+	}
+	if c.manifest == nil {
+		return false
+	}
+
+	m := c.manifest
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, entry := range m.Files {
+		if m.abs(entry.Output) == goPath {
+			return true
+		}
+	}
+	return false
+}
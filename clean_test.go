@@ -0,0 +1,185 @@
+package gox
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// generatedFile 返回带有 gox 生成文件头的文件内容，source 相对文件所在目录
+func generatedFile(source string) string {
+	return generatedMarker + " from " + source + ". DO NOT EDIT.\n\npackage p\n"
+}
+
+// orphanTree 包含一个正常的源文件，以及各种位于编译范围之外、源文件已不存在的生成文件
+var orphanTree = map[string]string{
+	"dao/user.gox.go":                      testSource("dao"),
+	"dao/old_gen.go":                       generatedFile("old.gox.go"),
+	"dao/hand_gen.go":                      "package dao\n",
+	"vendor/example.com/lib/q_gen.go":      generatedFile("q.gox.go"),
+	"testdata/fixture_gen.go":              generatedFile("fixture.gox.go"),
+	".cache/x_gen.go":                      generatedFile("x.gox.go"),
+	"legacy/old_gen.go":                    generatedFile("old.gox.go"),
+	"ignored/old_gen.go":                   generatedFile("old.gox.go"),
+	".goxignore":                           "ignored/\n",
+	"node_modules/pkg/generated/a_gen.go":  generatedFile("a.gox.go"),
+	"dao/sub/excluded_gen.go":              generatedFile("excluded.gox.go"),
+	"dao/sub/.goxignore":                   "excluded.gox.go\n",
+	"dao/nested/deep/gone_gen.go":          generatedFile("gone.gox.go"),
+	"dao/nested/deep/still_here_gen.go":    generatedFile("still_here.gox.go"),
+	"dao/nested/deep/still_here.gox.go":    testSource("deep"),
+	"dao/nested/deep/unrelated_file.go":    "package deep\n",
+	"dao/nested/deep/other_suffix_file.go": generatedFile("other.gox.go"),
+}
+
+func checkExists(t *testing.T, dir string, want map[string]bool) {
+	t.Helper()
+	for name, exists := range want {
+		if got := fileExists(filepath.Join(dir, filepath.FromSlash(name))); got != exists {
+			t.Errorf("%s exists = %v, want %v", name, got, exists)
+		}
+	}
+}
+
+func TestPruneOrphans(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, orphanTree)
+
+	c := newTestCompiler(dir)
+	c.Layout = LayoutAlongside
+	c.Exclude = []string{"legacy/"}
+	if err := c.Compile(); err != nil {
+		t.Fatal(err)
+	}
+
+	checkExists(t, dir, map[string]bool{
+		"dao/user_gen.go":                     true,
+		"dao/old_gen.go":                      false,
+		"dao/hand_gen.go":                     true,
+		"vendor/example.com/lib/q_gen.go":     true,
+		"testdata/fixture_gen.go":             true,
+		".cache/x_gen.go":                     true,
+		"legacy/old_gen.go":                   true,
+		"ignored/old_gen.go":                  true,
+		"node_modules/pkg/generated/a_gen.go": true,
+		"dao/sub/excluded_gen.go":             true,
+		"dao/nested/deep/gone_gen.go":         false,
+		"dao/nested/deep/still_here_gen.go":   true,
+	})
+}
+
+func TestPruneOrphansNonRecursive(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, orphanTree)
+
+	c := newTestCompiler(filepath.Join(dir, "dao"))
+	c.Layout = LayoutAlongside
+	c.NonRecursive = true
+	if err := c.Compile(); err != nil {
+		t.Fatal(err)
+	}
+
+	checkExists(t, dir, map[string]bool{
+		"dao/old_gen.go":                    false,
+		"dao/nested/deep/gone_gen.go":       true,
+		"dao/nested/deep/still_here_gen.go": true,
+	})
+}
+
+func TestClean(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, orphanTree)
+
+	c := newTestCompiler(dir)
+	c.Layout = LayoutAlongside
+	c.Exclude = []string{"legacy/"}
+	if err := c.Clean(); err != nil {
+		t.Fatal(err)
+	}
+
+	checkExists(t, dir, map[string]bool{
+		"dao/old_gen.go":                       false,
+		"dao/hand_gen.go":                      true,
+		"vendor/example.com/lib/q_gen.go":      true,
+		"testdata/fixture_gen.go":              true,
+		".cache/x_gen.go":                      true,
+		"legacy/old_gen.go":                    true,
+		"ignored/old_gen.go":                   true,
+		"node_modules/pkg/generated/a_gen.go":  true,
+		"dao/sub/excluded_gen.go":              true,
+		"dao/nested/deep/gone_gen.go":          false,
+		"dao/nested/deep/still_here_gen.go":    false,
+		"dao/nested/deep/other_suffix_file.go": true,
+	})
+}
+
+func TestCleanManifestOutputs(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"src/user.gox.go":         testSource("dao"),
+		"src/skipped/old.gox.go":  testSource("skipped"),
+		"src/skipped/.goxignore":  "*\n",
+		"out/foreign_gen.go":      "package dao\n",
+		"out/skipped/old_gen.go":  generatedFile("../../src/skipped/old.gox.go"),
+		"out/unrelated/other.txt": "",
+	})
+
+	c := newTestCompiler(filepath.Join(dir, "src"))
+	c.DestPath = filepath.Join(dir, "out")
+	c.Layout = LayoutMirror
+	c.Manifest = true
+	if err := c.Compile(); err != nil {
+		t.Fatal(err)
+	}
+	checkExists(t, dir, map[string]bool{"out/user_gen.go": true, "out/.gox-manifest.json": true})
+
+	c = newTestCompiler(filepath.Join(dir, "src"))
+	c.DestPath = filepath.Join(dir, "out")
+	c.Layout = LayoutMirror
+	if err := c.Clean(); err != nil {
+		t.Fatal(err)
+	}
+	checkExists(t, dir, map[string]bool{
+		"out/user_gen.go":        false,
+		"out/.gox-manifest.json": false,
+		"out/foreign_gen.go":     true,
+		"out/skipped/old_gen.go": true,
+	})
+}
+
+func TestWatchRemovesOnlyGeneratedOutputs(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"dao/user_gen.go":  "package dao\n\n// 手写的文件\n",
+		"dao/order_gen.go": generatedFile("order.gox.go"),
+	})
+
+	c := newTestCompiler(dir)
+	c.Layout = LayoutAlongside
+	if err := c.prepare(); err != nil {
+		t.Fatal(err)
+	}
+	c.overlay = &overlay{Replace: map[string]string{
+		filepath.Join(dir, "dao", "user.gox.go"): filepath.Join(dir, "dao", "user_gen.go"),
+	}}
+
+	deleted := map[string]bool{
+		filepath.Join(dir, "dao", "user.gox.go"):  true,
+		filepath.Join(dir, "dao", "order.gox.go"): true,
+	}
+	c.rebuildChanged(context.Background(), deleted, map[string]time.Time{})
+
+	checkExists(t, dir, map[string]bool{
+		"dao/user_gen.go":  true,
+		"dao/order_gen.go": false,
+	})
+	if len(c.overlay.Replace) != 0 {
+		t.Errorf("overlay still replaces %v", c.overlay.Replace)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "dao", "user_gen.go"))
+	if err != nil || string(data) != "package dao\n\n// 手写的文件\n" {
+		t.Errorf("hand-written file changed: %q, %v", data, err)
+	}
+}
@@ -0,0 +1,338 @@
+// gox 命令行工具，包装 gox.Compiler 提供 build/clean/check/version 子命令
+//
+// 用法示例:
+//
+//	go run github.com/llyb120/gox/cmd/gox build ./dao
+//
+// 在包中添加 //go:generate gox 后，go generate 会只编译该包目录下的 .gox.go 文件，
+// 并把生成文件写在源文件旁边。
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/llyb120/gox"
+)
+
+// 退出码
+const (
+	exitOK      = 0 // 成功
+	exitFailure = 1 // 编译或检查失败
+	exitUsage   = 2 // 命令行参数错误
+)
+
+const usage = `gox - GoX 模板编译器
+
+用法:
+  gox <命令> [参数] [路径]
+
+命令:
+  build    编译 .gox.go 文件，生成 _gen.go 文件
+  clean    移除生成的 _gen.go 文件
+  check    检查生成文件是否与 .gox.go 源文件一致，不写入任何文件
+  generate 只编译当前包目录下的 .gox.go 文件，生成文件写在源文件旁边
+  version  显示版本号
+
+路径默认为当前目录，可以是目录或单个 .gox.go 文件。
+在 go generate 中不带命令运行（//go:generate gox）时等同于 gox generate。
+使用 "gox <命令> -h" 查看命令的参数说明。
+`
+
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+func run(args []string) int {
+	// //go:generate gox [参数]
+	if gox.InGoGenerate() && (len(args) == 0 || strings.HasPrefix(args[0], "-")) {
+		return runGenerate(args)
+	}
+
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, usage)
+		return exitUsage
+	}
+
+	switch args[0] {
+	case "build":
+		return runBuild(args[1:])
+	case "clean":
+		return runClean(args[1:])
+	case "check":
+		return runCheck(args[1:])
+	case "generate":
+		return runGenerate(args[1:])
+	case "version":
+		fmt.Printf("gox %s\n", gox.Version)
+		return exitOK
+	case "help", "-h", "-help", "--help":
+		fmt.Print(usage)
+		return exitOK
+	default:
+		fmt.Fprintf(os.Stderr, "未知命令: %s\n\n%s", args[0], usage)
+		return exitUsage
+	}
+}
+
+// commandFlags 各子命令共用的参数
+type commandFlags struct {
+	fs        *flag.FlagSet
+	destPath  string
+	layout    string
+	format    string
+	lang      string
+	quiet     bool
+	debug     bool
+	noLine    bool
+	typeCheck bool
+	lenient   bool
+	include   stringList
+	exclude   stringList
+
+	config     string
+	noConfig   bool
+	smartScope bool
+	dialect    string
+	overlay    string
+	cacheDir   string
+}
+
+// stringList 可以重复指定的字符串参数
+type stringList []string
+
+func (s *stringList) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringList) Set(v string) error {
+	*s = append(*s, v)
+	return nil
+}
+
+// newCommandFlags 创建子命令的参数集合
+func newCommandFlags(name, desc string) *commandFlags {
+	cf := &commandFlags{fs: flag.NewFlagSet(name, flag.ContinueOnError)}
+	cf.fs.StringVar(&cf.destPath, "o", "", "生成文件的输出目录，未指定时生成文件写在各自的源文件旁边")
+	cf.fs.StringVar(&cf.layout, "layout", "", "输出布局: flat（平铺到输出目录，指定 -o 时默认）、mirror（保持源目录结构）、alongside（写在源文件旁边，未指定 -o 时默认）")
+	cf.fs.StringVar(&cf.config, "config", "", "项目配置文件路径，默认从源目录开始向上查找 gox.toml 或 gox.json")
+	cf.fs.BoolVar(&cf.noConfig, "no-config", false, "不读取项目配置文件")
+	cf.fs.BoolVar(&cf.smartScope, "smart-scope", false, "所有文件默认启用智能作用域模式")
+	cf.fs.StringVar(&cf.dialect, "dialect", "", "SQL 方言: mysql（默认）或 sqlite，生成的代码使用 ? 占位符")
+	cf.fs.StringVar(&cf.format, "format", "text", "输出格式: text 或 json（每个事件一行 JSON）")
+	cf.fs.StringVar(&cf.lang, "lang", "zh", "文本输出的语言: zh 或 en")
+	cf.fs.BoolVar(&cf.quiet, "q", false, "不输出进度信息，只输出错误")
+	cf.fs.BoolVar(&cf.debug, "debug", false, "启用调试模式，显示详细的错误信息和预处理后的代码")
+	cf.fs.BoolVar(&cf.debug, "d", false, "启用调试模式的简写形式")
+	cf.fs.BoolVar(&cf.lenient, "lenient", false, "关闭严格的模板语法检查，不配对的大括号等按 SQL 文本处理")
+	cf.fs.BoolVar(&cf.typeCheck, "typecheck", false, "生成后对所在的包做类型检查，在 .gox.go 源文件的位置报告类型错误")
+	cf.fs.BoolVar(&cf.noLine, "no-line", false, "不在生成文件中输出指向 .gox.go 源文件的 //line 指令")
+	cf.fs.Var(&cf.include, "include", "只编译匹配该模式的源文件（gitignore 语法，相对源目录），可以重复指定")
+	cf.fs.Var(&cf.exclude, "exclude", "跳过匹配该模式的文件和目录（gitignore 语法，相对源目录），可以重复指定")
+	cf.fs.StringVar(&cf.overlay, "overlay", "", "overlay 模式：生成文件写入缓存目录，并在该路径写入 go build -overlay 使用的 JSON 文件")
+	cf.fs.StringVar(&cf.cacheDir, "cache-dir", "", "overlay 模式下生成文件的缓存目录，默认在用户缓存目录下")
+	cf.fs.Usage = func() {
+		fmt.Fprintf(cf.fs.Output(), "用法: gox %s [参数] [路径]\n\n%s\n\n参数:\n", name, desc)
+		cf.fs.PrintDefaults()
+	}
+	return cf
+}
+
+// parse 解析参数并构造编译器，返回的退出码小于 0 表示继续执行
+func (cf *commandFlags) parse(args []string) (*gox.Compiler, int) {
+	// 允许参数出现在路径之后，例如 gox build ./dao -o ./out
+	var paths []string
+	for {
+		if err := cf.fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return nil, exitOK
+			}
+			return nil, exitUsage
+		}
+		if cf.fs.NArg() == 0 {
+			break
+		}
+		paths = append(paths, cf.fs.Arg(0))
+		args = cf.fs.Args()[1:]
+	}
+	if len(paths) > 1 {
+		fmt.Fprintf(os.Stderr, "只能指定一个路径，得到 %d 个\n", len(paths))
+		cf.fs.Usage()
+		return nil, exitUsage
+	}
+
+	// 未指定路径时由项目配置文件决定，没有配置文件时为当前目录
+	var srcPath string
+	if len(paths) == 1 {
+		srcPath = paths[0]
+		if _, err := os.Stat(srcPath); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return nil, exitFailure
+		}
+	}
+
+	var reporter gox.Reporter
+	switch {
+	case cf.format == "json":
+		reporter = gox.NewJSONReporter(os.Stdout)
+	case cf.format != "text":
+		fmt.Fprintf(os.Stderr, "未知的输出格式: %s\n", cf.format)
+		return nil, exitUsage
+	case cf.quiet:
+		reporter = gox.QuietReporter{}
+	default:
+		reporter = gox.NewTextReporter(os.Stdout, cf.lang)
+	}
+
+	// 命令行中出现的布尔参数即使为 false 也覆盖配置文件，例如 -lenient=false
+	var explicit gox.Option
+	cf.fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "smart-scope":
+			explicit |= gox.OptSmartScope
+		case "lenient":
+			explicit |= gox.OptLenient
+		case "typecheck":
+			explicit |= gox.OptTypeCheck
+		}
+	})
+
+	return &gox.Compiler{
+		SrcPath:    srcPath,
+		DestPath:   cf.destPath,
+		Layout:     gox.Layout(cf.layout),
+		DebugMode:  cf.debug,
+		Reporter:   reporter,
+		ConfigFile: cf.config,
+		NoConfig:   cf.noConfig,
+		Explicit:   explicit,
+		SmartScope: cf.smartScope,
+		Dialect:    gox.Dialect(cf.dialect),
+		Overlay:    cf.overlay,
+		CacheDir:   cf.cacheDir,
+
+		NoLineDirectives: cf.noLine,
+		TypeCheck:        cf.typeCheck,
+		Lenient:          cf.lenient,
+		Include:          cf.include,
+		Exclude:          cf.exclude,
+	}, -1
+}
+
+// exitWithError 输出错误并返回失败退出码
+// 文件级错误已经通过 Reporter 输出，只有使用 -q 时才需要再次输出
+func (cf *commandFlags) exitWithError(err error) int {
+	var errs gox.CompileErrors
+	if !errors.As(err, &errs) || cf.quiet {
+		fmt.Fprintln(os.Stderr, err)
+	}
+	return exitFailure
+}
+
+func runBuild(args []string) int {
+	cf := newCommandFlags("build", "编译 .gox.go 文件，生成 _gen.go 文件。")
+	var incremental, manifest, removeGenerated, force, keepOrphans, watch bool
+	var concurrency int
+	var stampTag string
+	var interval time.Duration
+	cf.fs.BoolVar(&incremental, "incremental", false, "启用增量编译，跳过已经是最新的文件")
+	cf.fs.BoolVar(&incremental, "i", false, "启用增量编译的简写形式")
+	cf.fs.BoolVar(&manifest, "manifest", false, "维护内容哈希清单，增量编译时依据内容哈希而非修改时间判断是否跳过")
+	cf.fs.BoolVar(&removeGenerated, "r", false, "编译前移除输出目录中已生成的文件")
+	cf.fs.BoolVar(&force, "force", false, "覆盖已存在但不是由 gox 生成的目标文件")
+	cf.fs.BoolVar(&keepOrphans, "keep-orphans", false, "保留源文件已不存在的生成文件")
+	cf.fs.StringVar(&stampTag, "stamp-tag", "", "编译前给源文件加上要求该标签的 //go:build 约束，例如 gox 或 ignore")
+	cf.fs.IntVar(&concurrency, "j", 0, "同时编译的文件数，默认为 CPU 核数")
+	cf.fs.BoolVar(&watch, "watch", false, "监听模式，持续运行并自动重新编译发生变化的文件")
+	cf.fs.BoolVar(&watch, "w", false, "监听模式的简写形式")
+	cf.fs.DurationVar(&interval, "interval", 0, "监听模式的轮询间隔，默认 500ms")
+
+	c, code := cf.parse(args)
+	if code >= 0 {
+		return code
+	}
+	c.Incremental = incremental
+	c.Manifest = manifest
+	c.RemoveGenerated = removeGenerated
+	c.Concurrency = concurrency
+	c.Force = force
+	c.KeepOrphans = keepOrphans
+	c.StampBuildTag = stampTag
+	c.WatchInterval = interval
+
+	// Ctrl+C 时等待正在写入的文件完成后退出
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if watch {
+		if cf.format == "text" && !cf.quiet {
+			if c.SrcPath != "" {
+				fmt.Printf("监听目录: %s\n", c.SrcPath)
+			} else {
+				fmt.Println("监听项目配置文件中的源目录（没有配置文件时为当前目录）")
+			}
+		}
+		if err := c.WatchContext(ctx); err != nil && !errors.Is(err, context.Canceled) {
+			return cf.exitWithError(err)
+		}
+		return exitOK
+	}
+
+	if err := c.CompileContext(ctx); err != nil {
+		return cf.exitWithError(err)
+	}
+	return exitOK
+}
+
+func runClean(args []string) int {
+	cf := newCommandFlags("clean", "移除输出目录中生成的 _gen.go 文件。")
+	c, code := cf.parse(args)
+	if code >= 0 {
+		return code
+	}
+
+	if err := c.Clean(); err != nil {
+		return cf.exitWithError(err)
+	}
+	return exitOK
+}
+
+func runCheck(args []string) int {
+	cf := newCommandFlags("check", "在内存中编译 .gox.go 文件并与现有生成文件比较，输出差异；\n有文件过期时以非零退出码结束，不写入任何文件。")
+	c, code := cf.parse(args)
+	if code >= 0 {
+		return code
+	}
+
+	if err := c.Check(); err != nil {
+		return cf.exitWithError(err)
+	}
+	return exitOK
+}
+
+func runGenerate(args []string) int {
+	cf := newCommandFlags("generate", "供 //go:generate gox 使用：只编译包目录（默认为当前目录）下的 .gox.go 文件，\n不进入子目录，未指定 -o 时生成文件写在源文件旁边。")
+	var force bool
+	cf.fs.BoolVar(&force, "force", false, "覆盖已存在但不是由 gox 生成的目标文件")
+
+	c, code := cf.parse(args)
+	if code >= 0 {
+		return code
+	}
+	if err := c.SetupGenerate(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFailure
+	}
+	c.Force = force
+
+	if err := c.Compile(); err != nil {
+		return cf.exitWithError(err)
+	}
+	return exitOK
+}
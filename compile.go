@@ -1,13 +1,16 @@
 package gox
 
 import (
+	"context"
 	"fmt"
 	"io/fs"
-	"log"
 	"os"
 	"path/filepath"
+	"runtime"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/llyb120/gox/parser"
 )
@@ -18,191 +21,492 @@ type Compiler struct {
 	DebugMode       bool   // 开启调试模式
 	RemoveGenerated bool   // 移除生成的文件目录
 
-	SrcPath  string // 源文件路径
-	DestPath string // 目标文件路径
+	SrcPath  string // 源文件路径，默认为当前目录
+	DestPath string // 目标文件路径，默认与源文件目录相同
+	Layout   Layout // 生成文件的输出布局，设置了 DestPath 时默认平铺到 DestPath，否则默认写在源文件旁边
+
+	// ConfigFile 项目配置文件路径，为空时从 SrcPath 开始逐级向上查找 gox.toml 或 gox.json
+	// 已设置的字段优先于配置文件；NoConfig 为 true 时不读取配置文件
+	ConfigFile string
+	NoConfig   bool
+
+	// Explicit 标记显式设置的布尔选项，被标记的选项即使为 false 也不使用配置文件中的值，
+	// 例如命令行中的 -lenient=false
+	Explicit Option
+
+	SourceSuffix string  // 源文件后缀，默认 .gox.go
+	OutputSuffix string  // 生成文件后缀，默认 _gen.go，必须以 .go 结尾
+	SmartScope   bool    // 所有文件默认启用智能作用域模式，等同于在每个文件中写 gox:smart_scope 注释
+	Dialect      Dialect // SQL 方言，默认 DialectMySQL；生成的代码使用 ? 占位符，不支持其他占位符的方言
+	Header       string  // 追加到生成文件头的注释文本，例如版权声明
+
+	// NonRecursive 只处理 SrcPath 目录本身的 .gox.go 文件，不进入子目录
+	NonRecursive bool
+
+	// Include 目录编译时只处理匹配任一模式的源文件，为空时处理全部
+	// Exclude 目录编译时跳过匹配任一模式的文件和目录
+	// 模式使用 gitignore 语法，相对 SrcPath；各级目录下的 .goxignore 文件也会被读取，
+	// 隐藏目录以及 vendor、testdata、node_modules 目录默认跳过
+	Include []string
+	Exclude []string
+
+	Concurrency int      // 同时编译的文件数，默认为 GOMAXPROCS
+	Reporter    Reporter // 编译事件的接收者，默认输出中文文本到标准输出
+
+	// Force 允许覆盖已存在但不是由 gox 生成的目标文件
+	Force bool
+
+	// StampBuildTag 非空时编译前确保每个源文件带有要求该标签的 //go:build 约束（通常为 "gox" 或 "ignore"），
+	// 避免源文件和生成文件同时编译进同一个包；源文件约束中的 ignore、gox 以及该标签不会复制到生成文件
+	StampBuildTag string
+
+	// Overlay 非空时启用 overlay 模式：生成文件按源目录结构写入 CacheDir 而不是源码树（忽略 DestPath 和 Layout），
+	// 并在 Overlay 路径写入 go build -overlay 使用的 JSON 文件，把每个 .gox.go 源文件替换为对应的生成文件，
+	// 之后使用 go build -overlay=<Overlay> ./... 编译；源文件通常带有 //go:build ignore 约束，不使用 overlay 时不参与构建
+	Overlay  string
+	CacheDir string // overlay 模式下生成文件的缓存目录，默认为用户缓存目录下按源目录区分的子目录
+
+	// Lenient 关闭严格的模板语法检查，默认检查未闭合或多余的大括号、空的 #{} / ${} 以及无法解析的表达式，
+	// 并报告其在 .gox.go 源文件中的行列；关闭后这些内容按旧版本的方式当作 SQL 文本处理
+	Lenient bool
+
+	// TypeCheck 生成后使用 go/types 对生成文件所在的包做类型检查，
+	// 导入的包从本地源码加载，只使用模块缓存、vendor 目录和 replace 指向的目录，不会下载模块，依赖不在本地时报错；
+	// 错误通过 //line 指令报告在 .gox.go 源文件的行列上，
+	// 有错误的文件标记为失败（已写入的生成文件保留），下次增量编译时重新生成和检查
+	TypeCheck bool
+
+	// NoLineDirectives 不在生成文件中输出 //line 指令，
+	// 默认输出，使编译错误、go vet 结果和 panic 堆栈指向 .gox.go 源文件
+	NoLineDirectives bool
+
+	// KeepOrphans 目录编译后保留源文件已不存在的生成文件，默认会删除这些孤立文件
+	KeepOrphans bool
+
+	// DryRun 只在内存中解析和生成，与现有目标文件比较并报告差异，不修改磁盘上的任何文件
+	DryRun bool
+
+	// Manifest 在输出根目录维护内容哈希清单（.gox-manifest.json），
+	// 增量编译时依据源文件哈希、gox 版本和编译选项判断是否跳过，而非修改时间
+	Manifest bool
+
+	WatchInterval time.Duration // 监听模式的轮询间隔，默认 500ms
+	WatchDebounce time.Duration // 监听模式的防抖时间，默认 300ms
+
+	manifest      *manifest // 启用 Manifest 时加载的增量编译清单
+	overlay       *overlay  // overlay 模式下加载的替换记录
+	configApplied bool      // 项目配置文件已经应用
 }
 
-func (c *Compiler) Compile() {
+// Compile 编译所有 .gox.go 文件
+// 单个文件失败不会中断其他文件的编译，所有失败的文件以 CompileErrors 的形式一起返回
+func (c *Compiler) Compile() error {
+	return c.CompileContext(context.Background())
+}
+
+// CompileContext 与 Compile 相同，ctx 取消时停止遍历目录、不再开始编译新的文件，
+// 等待正在编译的文件完成后报告已完成的文件并返回 ctx.Err()
+// 生成文件通过临时文件加重命名的方式写入，取消时不会留下不完整的文件，清单只记录已完成的文件
+func (c *Compiler) CompileContext(ctx context.Context) error {
 	// 添加增量编译参数
 	var incremental = c.Incremental
-	var singleFile = c.SingleFile
 	var debugMode = c.DebugMode
 	var removeGenerated = c.RemoveGenerated
-	// flag.BoolVar(&incremental, "incremental", false, "启用增量编译，跳过已经是最新的文件")
-	// flag.BoolVar(&incremental, "i", false, "启用增量编译的简写形式")
-	// flag.StringVar(&singleFile, "f", "", "单独编译一个文件")
-	// flag.BoolVar(&debugMode, "debug", false, "启用调试模式，显示详细的错误信息和预处理后的代码")
-	// flag.BoolVar(&debugMode, "d", false, "启用调试模式的简写形式")
-	// flag.StringVar(&removeGenerated, "r", "", "移除生成的文件目录")
-	// flag.Parse()
-
-	if singleFile != "" {
-		if err := c.processGoxFile(singleFile, incremental, debugMode); err != nil {
-			log.Fatal(err)
-		}
-		return
-	}
-
-	// 移除简单的 sleep，使用更可靠的文件检查机制
-	// time.Sleep(100 * time.Millisecond)
 
-	// 获取工作目录
-	// path, err := os.Getwd()
-	// if err != nil {
-	// 	log.Fatal(err)
-	// }
+	if err := c.prepare(); err != nil {
+		return err
+	}
 
-	// path := filepath.Join(cwd, "../")
-	// 换算绝对路径
-	path := c.SrcPath
-	if !filepath.IsAbs(path) {
-		cwd, err := os.Getwd()
+	// DryRun 模式不读写清单，也不移除任何文件
+	if c.DryRun {
+		incremental = false
+		removeGenerated = false
+	} else if c.Manifest {
+		m, err := loadManifest(c.outputRoot(), c.manifestOptions())
 		if err != nil {
-			log.Fatal(err)
+			return err
 		}
-		path = filepath.Join(cwd, path)
-		c.SrcPath = path
+		c.manifest = m
 	}
-	destPath := c.DestPath
-	if !filepath.IsAbs(destPath) {
-		cwd, err := os.Getwd()
+	if !c.DryRun && c.overlayMode() {
+		o, err := loadOverlay(c.Overlay)
 		if err != nil {
-			log.Fatal(err)
+			return err
 		}
-		destPath = filepath.Join(cwd, destPath)
-		c.DestPath = destPath
+		c.overlay = o
 	}
 
-	// 如果根目录是cmd，取父级目录
-	// if filepath.Base(path) == "cmd" {
-	// 	path = filepath.Dir(path)
-	// }
+	buildErr := c.build(ctx, incremental, debugMode, removeGenerated)
+
+	// 无论编译是否全部成功都保存清单，已成功的文件下次可以跳过
+	if c.manifest != nil {
+		if err := c.manifest.save(); err != nil && buildErr == nil {
+			return err
+		}
+	}
+	if c.overlay != nil {
+		if err := c.overlay.save(); err != nil && buildErr == nil {
+			return err
+		}
+	}
+	return buildErr
+}
+
+// build 根据 SingleFile / SrcPath 编译单个文件或整个目录
+func (c *Compiler) build(ctx context.Context, incremental bool, debugMode bool, removeGenerated bool) error {
+	if c.SingleFile != "" {
+		return c.processFiles(ctx, []string{c.SingleFile}, incremental, debugMode)
+	}
+
+	path := c.SrcPath
 
 	// 检查是文件还是目录
 	info, err := os.Stat(path)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
-	if removeGenerated && c.SingleFile == "" {
-		// 移除该目录下所有_gen.go文件
-		_ = filepath.Walk(c.DestPath, func(path string, info os.FileInfo, err error) error {
-			if err != nil {
-				return err
-			}
-			if info.IsDir() {
-				return nil
-			}
-			if strings.HasSuffix(path, "_gen.go") {
-				fmt.Printf("移除文件：%s\n", path)
-				err = os.Remove(path)
-				if err != nil {
-					return err
-				}
-			}
-			return nil
-		})
+	if removeGenerated {
+		// 移除该目录下所有生成的文件
+		if err := c.removeGeneratedFiles(); err != nil {
+			return fmt.Errorf("移除生成文件失败: %w", err)
+		}
 	}
 
-	if info.IsDir() {
-		if err := c.processDirectory(path, incremental, debugMode); err != nil {
-			log.Fatal(err)
-		}
-	} else {
-		if err := c.processGoxFile(path, incremental, debugMode); err != nil {
-			log.Fatal(err)
-		}
+	if !info.IsDir() {
+		return c.processFiles(ctx, []string{path}, incremental, debugMode)
 	}
-}
 
-func (c *Compiler) processDirectory(dir string, incremental bool, debugMode bool) error {
-	fmt.Printf("处理目录: %s\n", dir)
+	buildErr := c.processDirectory(ctx, path, incremental, debugMode)
 
-	var wg sync.WaitGroup
-	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
+	// 目录编译后删除源文件已不存在的生成文件，编译被取消时跳过
+	if !c.DryRun && !c.KeepOrphans && ctx.Err() == nil {
+		if err := c.pruneOrphans(); err != nil && buildErr == nil {
 			return err
 		}
+	}
+	return buildErr
+}
 
-		// 跳过目录
-		if d.IsDir() {
-			return nil
-		}
+// processDirectory 编译目录下的所有 .gox.go 文件，收集每个文件的错误
+func (c *Compiler) processDirectory(ctx context.Context, dir string, incremental bool, debugMode bool) error {
+	files, err := c.collectGoxFiles(ctx, dir)
+	if err != nil {
+		return err
+	}
 
-		// 只处理 .gox.go 文件
-		if !strings.HasSuffix(path, ".gox.go") {
-			return nil
+	// 多个源文件映射到同一个目标文件时直接报错，避免互相覆盖
+	if conflicts := c.outputConflicts(files); len(conflicts) > 0 {
+		results := make([]*fileResult, len(conflicts))
+		for i, fe := range conflicts {
+			results[i] = &fileResult{Source: fe.File, Status: fileFailed, Err: fe.Err}
 		}
+		return c.reportResults(results)
+	}
 
+	return c.processFiles(ctx, files, incremental, debugMode)
+}
+
+// processFiles 使用固定数量的 worker 并发编译文件
+// worker 开始处理文件时派发 FileStart，全部完成后按源文件路径顺序报告每个文件的结果，保证日志稳定；
+// ctx 取消时不再派发新的文件，只报告已完成的文件并返回 ctx.Err()
+func (c *Compiler) processFiles(ctx context.Context, files []string, incremental bool, debugMode bool) error {
+	files = append([]string(nil), files...)
+	sort.Strings(files)
+
+	workers := c.Concurrency
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers > len(files) {
+		workers = len(files)
+	}
+
+	results := make([]*fileResult, len(files))
+	jobs := make(chan int)
+	rep := c.reporter()
+	var (
+		wg      sync.WaitGroup
+		startMu sync.Mutex // 串行派发各个 worker 的 FileStart
+	)
+	for w := 0; w < workers; w++ {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			err = c.processGoxFile(path, incremental, debugMode)
-			if err != nil {
-				panic(err)
+			for i := range jobs {
+				if ctx.Err() != nil {
+					continue
+				}
+				startMu.Lock()
+				rep.FileStart(files[i])
+				startMu.Unlock()
+				results[i] = c.processGoxFile(files[i], incremental, debugMode)
 			}
 		}()
-		return nil
-	})
-
+	}
+dispatch:
+	for i := range files {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(jobs)
 	wg.Wait()
-	return nil
+
+	if err := ctx.Err(); err != nil {
+		// 未开始编译的文件不计入结果
+		done := make([]*fileResult, 0, len(results))
+		for _, r := range results {
+			if r != nil {
+				done = append(done, r)
+			}
+		}
+		_ = c.reportResults(done)
+		return err
+	}
+
+	if c.TypeCheck {
+		c.typeCheck(results)
+	}
+	return c.reportResults(results)
 }
 
-func (c *Compiler) processGoxFile(goxPath string, incremental bool, debugMode bool) error {
-	fmt.Printf("处理文件: %s\n", goxPath)
+// fileStatus 单个文件的编译状态
+type fileStatus int
+
+const (
+	fileGenerated fileStatus = iota // 已生成目标文件
+	fileUnchanged                   // 重新生成但内容与目标文件相同，未写入
+	fileSkipped                     // 目标文件已是最新，跳过
+	fileStale                       // DryRun 模式下目标文件与生成结果不一致
+	fileFailed                      // 编译失败
+)
+
+// fileResult 单个文件的编译结果
+type fileResult struct {
+	Source string     // 源文件路径
+	Output string     // 目标文件路径
+	Status fileStatus // 编译状态
+	Diff   string     // DryRun 模式下目标文件与生成结果的 unified diff
+	Code   []byte     // 启用 TypeCheck 时保存生成的代码，供类型检查使用
+	Err    error      // 失败原因
+}
 
+// processGoxFile 编译单个 .gox.go 文件，返回编译结果
+func (c *Compiler) processGoxFile(goxPath string, incremental bool, debugMode bool) *fileResult {
 	// 生成目标文件路径
-	fileName := filepath.Base(goxPath)
-	fileName = strings.TrimSuffix(fileName, ".gox.go") + "_gen.go"
-	goPath := filepath.Join(c.DestPath, fileName)
-
-	// 增量编译检查
-	if incremental {
-		if shouldSkip, err := shouldSkipFile(goxPath, goPath); err != nil {
-			fmt.Printf("检查文件时间时出错 %s: %v\n", goxPath, err)
-		} else if shouldSkip {
-			fmt.Printf("跳过文件（目标文件已是最新）: %s\n", goxPath)
-			return nil
-		}
+	goPath := c.outputPath(goxPath)
+	result := &fileResult{Source: goxPath, Output: goPath, Status: fileFailed}
+	if c.overlay != nil {
+		defer c.overlay.update(result)
 	}
 
 	// 读取源文件
 	content, err := os.ReadFile(goxPath)
 	if err != nil {
-		return fmt.Errorf("读取文件失败 %s: %v", goxPath, err)
+		result.Err = fmt.Errorf("读取文件失败 %s: %v", goxPath, err)
+		return result
 	}
 
-	// 直接重写目标文件，无需先删除
+	// 给源文件加上构建约束，避免源文件和生成文件同时编译进同一个包
+	if c.StampBuildTag != "" && !c.DryRun {
+		stamped, changed, err := parser.StampBuildTag(content, c.StampBuildTag)
+		if err != nil {
+			result.Err = fmt.Errorf("添加构建约束失败 %s: %v", goxPath, err)
+			return result
+		}
+		if changed {
+			perm := os.FileMode(0644)
+			if info, err := os.Stat(goxPath); err == nil {
+				perm = info.Mode().Perm()
+			}
+			if _, err := writeFileAtomic(goxPath, stamped, perm); err != nil {
+				result.Err = fmt.Errorf("写入文件失败 %s: %v", goxPath, err)
+				return result
+			}
+			content = stamped
+		}
+	}
 
-	// 给源文件添加编译忽略指令
-	//if err := addBuildIgnore(goxPath, string(content)); err != nil {
-	//	return fmt.Errorf("添加编译忽略指令失败: %v", err)
-	//}
+	var srcHash string
+	if c.manifest != nil {
+		srcHash = hashBytes(content)
+	}
+
+	// 增量编译检查：启用清单时比较内容哈希，否则比较修改时间
+	if incremental && c.manifest != nil {
+		if c.manifest.upToDate(goxPath, goPath, srcHash) {
+			result.Status = fileSkipped
+			return result
+		}
+	} else if incremental {
+		// 检查文件时间出错时不跳过，直接重新编译
+		if shouldSkip, err := shouldSkipFile(goxPath, goPath); err == nil && shouldSkip {
+			result.Status = fileSkipped
+			return result
+		}
+	}
 
-	// 解析并生成目标文件
-	p := parser.NewParser()
-	p.SetDebugMode(debugMode) // 设置调试模式
-	goxFile, err := p.ParseFile(goxPath, content)
+	generated, err := c.generate(goxPath, goPath, content, debugMode)
 	if err != nil {
-		return fmt.Errorf("解析文件失败: %v", err)
+		if c.manifest != nil {
+			c.manifest.forget(goxPath)
+		}
+		result.Err = err
+		return result
+	}
+	if c.TypeCheck {
+		result.Code = generated
 	}
 
-	// 生成Go代码
-	generator := parser.NewGenerator()
-	generated, err := generator.GenerateFile(goxFile)
+	// DryRun 模式只比较生成结果与现有目标文件
+	if c.DryRun {
+		existing, err := os.ReadFile(goPath)
+		if err != nil && !os.IsNotExist(err) {
+			result.Err = fmt.Errorf("读取文件失败 %s: %v", goPath, err)
+			return result
+		}
+		if diff := unifiedDiff(goPath, goPath+" (生成结果)", existing, generated); diff != "" {
+			result.Status = fileStale
+			result.Diff = diff
+			result.Err = &StaleError{Output: goPath, Missing: err != nil, Diff: diff}
+			return result
+		}
+		result.Status = fileSkipped
+		return result
+	}
+
+	// 不覆盖不是由 gox 生成的同名文件，除非设置了 Force
+	if !c.Force && !c.ownsOutput(goPath) {
+		result.Err = fmt.Errorf("目标文件 %s 已存在且不是 gox 生成的文件（缺少 %q 文件头），如需覆盖请使用 Force", goPath, generatedMarker)
+		return result
+	}
+
+	// 写入目标文件，内容未变化时不改写，避免更新修改时间导致 Go 构建缓存失效
+	changed, err := writeFileAtomic(goPath, generated, 0644)
 	if err != nil {
-		return fmt.Errorf("生成代码失败: %v", err)
+		result.Err = fmt.Errorf("写入文件失败 %s: %v", goPath, err)
+		return result
+	}
+	if c.manifest != nil {
+		c.manifest.record(goxPath, goPath, srcHash, generated)
 	}
 
-	// goPath = strings.Replace(goPath, "v3_source", "v3", 1)
+	result.Status = fileGenerated
+	if !changed {
+		result.Status = fileUnchanged
+	}
+	return result
+}
 
-	// 写入目标文件
-	if err := os.WriteFile(goPath, generated, 0644); err != nil {
-		return fmt.Errorf("写入文件失败 %s: %v", goPath, err)
+// collectGoxFiles 收集路径下所有 .gox.go 文件，root 为文件时直接返回该文件；ctx 取消时停止遍历
+func (c *Compiler) collectGoxFiles(ctx context.Context, root string) ([]string, error) {
+	filter, err := c.newSourceFilter(root)
+	if err != nil {
+		return nil, err
 	}
 
-	fmt.Printf("生成文件: %s\n", goPath)
+	var files []string
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		// 跳过目录
+		if d.IsDir() {
+			if c.skipSubdir(root, path) || filter.skip(path, true) {
+				return filepath.SkipDir
+			}
+			return filter.enterDir(path)
+		}
+
+		// 只处理 .gox.go 文件
+		if !strings.HasSuffix(path, c.sourceSuffix()) || filter.skip(path, false) {
+			return nil
+		}
+
+		files = append(files, path)
+		return nil
+	})
+	return files, err
+}
+
+// skipSubdir 非递归模式下跳过 root 之外的子目录
+func (c *Compiler) skipSubdir(root, dir string) bool {
+	return c.NonRecursive && dir != root
+}
+
+// generate 解析 .gox.go 文件内容，返回生成的 Go 代码（不写入磁盘）
+// goPath 为目标文件路径，生成文件头中记录源文件相对目标文件所在目录的路径；
+// overlay 模式下生成文件在编译时代替源文件，记录源文件的绝对路径
+func (c *Compiler) generate(goxPath, goPath string, content []byte, debugMode bool) ([]byte, error) {
+	sourceName := relativeSourceName(goxPath, goPath)
+	if c.overlayMode() {
+		sourceName = filepath.ToSlash(goxPath)
+	}
+	return CompileSource(goxPath, content, Options{
+		SourceName:       sourceName,
+		DebugMode:        debugMode,
+		NoLineDirectives: c.NoLineDirectives,
+		SmartScope:       c.SmartScope,
+		Dialect:          c.Dialect,
+		Header:           c.Header,
+		Lenient:          c.Lenient,
+		StampBuildTag:    c.StampBuildTag,
+	})
+}
+
+// Check 以 DryRun 模式编译，检查所有生成文件是否与源文件一致
+// 有任何文件过期或缺失时返回 CompileErrors，其中每个过期文件对应一个 *StaleError
+// 检查结束后恢复原来的 DryRun 设置，之后仍可用同一个编译器正常编译
+func (c *Compiler) Check() error {
+	defer func(dryRun bool) { c.DryRun = dryRun }(c.DryRun)
+	c.DryRun = true
+	return c.Compile()
+}
+
+// resolvePaths 将 SrcPath、DestPath 以及 overlay 模式使用的路径换算为绝对路径
+func (c *Compiler) resolvePaths() error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	if !filepath.IsAbs(c.SrcPath) {
+		c.SrcPath = filepath.Join(cwd, c.SrcPath)
+	}
+	if c.DestPath == "" {
+		// 没有输出目录时平铺会把子目录中其他包的文件写进源目录，因此默认写在源文件旁边
+		if c.Layout == "" {
+			c.Layout = LayoutAlongside
+		}
+		c.DestPath = c.srcRoot()
+	} else if !filepath.IsAbs(c.DestPath) {
+		c.DestPath = filepath.Join(cwd, c.DestPath)
+	}
+	if c.SingleFile != "" && !filepath.IsAbs(c.SingleFile) {
+		c.SingleFile = filepath.Join(cwd, c.SingleFile)
+	}
+
+	if !c.overlayMode() {
+		return nil
+	}
+	if !filepath.IsAbs(c.Overlay) {
+		c.Overlay = filepath.Join(cwd, c.Overlay)
+	}
+	if c.CacheDir == "" {
+		dir, err := defaultCacheDir(c.srcRoot())
+		if err != nil {
+			return err
+		}
+		c.CacheDir = dir
+	} else if !filepath.IsAbs(c.CacheDir) {
+		c.CacheDir = filepath.Join(cwd, c.CacheDir)
+	}
 	return nil
 }
 
@@ -228,45 +532,3 @@ func shouldSkipFile(srcPath, destPath string) (bool, error) {
 	// 如果目标文件的修改时间大于等于源文件，则跳过
 	return destInfo.ModTime().Compare(srcInfo.ModTime()) >= 0, nil
 }
-
-func addBuildIgnore(filePath, content string) error {
-	lines := strings.Split(content, "\n")
-
-	// 检查是否已经有编译忽略指令
-	hasIgnore := false
-	packageIndex := -1
-
-	for i, line := range lines {
-		trimmed := strings.TrimSpace(line)
-		if strings.HasPrefix(trimmed, "//go:build ignore") ||
-			strings.HasPrefix(trimmed, "// +build ignore") {
-			hasIgnore = true
-		}
-		if strings.HasPrefix(trimmed, "package ") {
-			packageIndex = i
-			break
-		}
-	}
-
-	// 如果已经有忽略指令，不需要添加
-	if hasIgnore {
-		return nil
-	}
-
-	// 在package行前添加编译忽略指令
-	if packageIndex == -1 {
-		return fmt.Errorf("找不到package声明")
-	}
-
-	newLines := make([]string, 0, len(lines)+3)
-
-	// 添加到package行之前
-	newLines = append(newLines, lines[:packageIndex]...)
-	newLines = append(newLines, "//go:build ignore")
-	newLines = append(newLines, "// +build ignore")
-	newLines = append(newLines, "")
-	newLines = append(newLines, lines[packageIndex:]...)
-
-	newContent := strings.Join(newLines, "\n")
-	return os.WriteFile(filePath, []byte(newContent), 0644)
-}
@@ -0,0 +1,269 @@
+package gox
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// configFiles 项目配置文件名，同一目录下同时存在时优先使用 gox.toml
+var configFiles = []string{"gox.toml", "gox.json"}
+
+// 默认的源文件和生成文件后缀
+const (
+	defaultSourceSuffix = ".gox.go"
+	defaultOutputSuffix = "_gen.go"
+)
+
+// Config 项目配置文件（gox.toml 或 gox.json）的内容
+// 路径相对配置文件所在目录；Compiler 中已设置的字段（以及命令行参数）优先于配置文件，
+// 布尔选项需要在 Compiler.Explicit 中标记才能用 false 覆盖配置文件
+// 一个配置文件只描述一组源目录到输出目录的映射（src 和 dest），多个源目录需要各自放置配置文件或分别编译
+type Config struct {
+	Src          string   `json:"src"`           // 源文件目录
+	Dest         string   `json:"dest"`          // 生成文件目录
+	Layout       Layout   `json:"layout"`        // 输出布局
+	SourceSuffix string   `json:"source_suffix"` // 源文件后缀，默认 .gox.go
+	OutputSuffix string   `json:"output_suffix"` // 生成文件后缀，默认 _gen.go
+	SmartScope   bool     `json:"smart_scope"`   // 所有文件默认启用智能作用域模式
+	Dialect      Dialect  `json:"dialect"`       // SQL 方言，只能是使用 ? 占位符的方言
+	Header       string   `json:"header"`        // 追加到生成文件头的注释文本
+	StampTag     string   `json:"stamp_tag"`     // 编译前给源文件加上要求该标签的构建约束
+	Lenient      bool     `json:"lenient"`       // 关闭严格的模板语法检查
+	TypeCheck    bool     `json:"type_check"`    // 生成后对所在的包做类型检查
+	Overlay      string   `json:"overlay"`       // 启用 overlay 模式，写入 go build -overlay 使用的 JSON 文件
+	CacheDir     string   `json:"cache_dir"`     // overlay 模式下生成文件的缓存目录
+	Concurrency  int      `json:"concurrency"`   // 同时编译的文件数
+	Include      []string `json:"include"`       // 只编译匹配的源文件（gitignore 语法）
+	Exclude      []string `json:"exclude"`       // 跳过匹配的文件和目录（gitignore 语法）
+
+	dir string // 配置文件所在目录
+}
+
+// Option 配置文件中也可以设置的布尔选项，用于 Compiler.Explicit
+type Option uint
+
+const (
+	OptSmartScope Option = 1 << iota // SmartScope
+	OptLenient                       // Lenient
+	OptTypeCheck                     // TypeCheck
+)
+
+// Dialect SQL 方言
+// 生成的代码统一使用 ? 作为参数占位符，因此只接受使用 ? 占位符的方言；
+// 配置其他方言（例如 postgres）时报错，避免生成在目标数据库上无法执行的 SQL
+type Dialect string
+
+const (
+	DialectMySQL  Dialect = "mysql" // 默认
+	DialectSQLite Dialect = "sqlite"
+)
+
+// valid 检查方言是否受支持，空值等同于 DialectMySQL
+func (d Dialect) valid() bool {
+	switch d {
+	case "", DialectMySQL, DialectSQLite:
+		return true
+	}
+	return false
+}
+
+// checkDialect 校验 SQL 方言
+func checkDialect(d Dialect) error {
+	if !d.valid() {
+		return fmt.Errorf("不支持的 SQL 方言: %q，生成的代码使用 ? 占位符，只支持 mysql 和 sqlite", d)
+	}
+	return nil
+}
+
+// FindConfig 从 dir 开始逐级向上查找项目配置文件，找不到时返回空字符串
+func FindConfig(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for {
+		for _, name := range configFiles {
+			path := filepath.Join(dir, name)
+			if info, err := os.Stat(path); err == nil && !info.IsDir() {
+				return path, nil
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// LoadConfig 读取项目配置文件，根据扩展名按 TOML 或 JSON 解析，未知的配置项会返回错误
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取配置文件失败: %v", err)
+	}
+
+	// TOML 先转换为 JSON，两种格式共用同一套字段定义和校验
+	if strings.EqualFold(filepath.Ext(path), ".toml") {
+		values, err := parseTOML(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("解析配置文件失败 %s: %v", path, err)
+		}
+		if data, err = json.Marshal(values); err != nil {
+			return nil, err
+		}
+	}
+
+	cfg := &Config{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(cfg); err != nil {
+		return nil, fmt.Errorf("解析配置文件失败 %s: %v", path, err)
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	cfg.dir = filepath.Dir(abs)
+	return cfg, nil
+}
+
+// path 将配置中的相对路径换算为绝对路径
+func (cfg *Config) path(p string) string {
+	if p == "" || filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(cfg.dir, p)
+}
+
+// applyConfig 查找并应用项目配置文件，只填充 Compiler 中尚未设置的字段
+// 布尔字段为 false 时视为未设置，除非在 Explicit 中标记
+func (c *Compiler) applyConfig() error {
+	if c.NoConfig || c.configApplied {
+		return nil
+	}
+
+	path := c.ConfigFile
+	if path == "" {
+		// 从 SrcPath（或单独编译的文件）所在目录开始向上查找
+		start := c.SrcPath
+		if c.SingleFile != "" {
+			start = c.SingleFile
+		}
+		if start == "" {
+			start = "."
+		}
+		if info, err := os.Stat(start); err == nil && !info.IsDir() {
+			start = filepath.Dir(start)
+		}
+
+		found, err := FindConfig(start)
+		if err != nil {
+			return err
+		}
+		if found == "" {
+			c.configApplied = true
+			return nil
+		}
+		path = found
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		return err
+	}
+
+	if c.SrcPath == "" {
+		c.SrcPath = cfg.path(cfg.Src)
+	}
+	if c.DestPath == "" {
+		c.DestPath = cfg.path(cfg.Dest)
+	}
+	if c.Layout == "" {
+		c.Layout = cfg.Layout
+	}
+	if c.SourceSuffix == "" {
+		c.SourceSuffix = cfg.SourceSuffix
+	}
+	if c.OutputSuffix == "" {
+		c.OutputSuffix = cfg.OutputSuffix
+	}
+	if c.Explicit&OptSmartScope == 0 && !c.SmartScope {
+		c.SmartScope = cfg.SmartScope
+	}
+	if c.Dialect == "" {
+		c.Dialect = cfg.Dialect
+	}
+	if c.Header == "" {
+		c.Header = cfg.Header
+	}
+	if c.StampBuildTag == "" {
+		c.StampBuildTag = cfg.StampTag
+	}
+	if c.Explicit&OptLenient == 0 && !c.Lenient {
+		c.Lenient = cfg.Lenient
+	}
+	if c.Explicit&OptTypeCheck == 0 && !c.TypeCheck {
+		c.TypeCheck = cfg.TypeCheck
+	}
+	if c.Overlay == "" {
+		c.Overlay = cfg.path(cfg.Overlay)
+	}
+	if c.CacheDir == "" {
+		c.CacheDir = cfg.path(cfg.CacheDir)
+	}
+	if c.Concurrency == 0 {
+		c.Concurrency = cfg.Concurrency
+	}
+	if len(c.Include) == 0 {
+		c.Include = cfg.Include
+	}
+	// 忽略规则按 gitignore 语义合并，Compiler 中的规则在后面，优先级更高
+	c.Exclude = append(append([]string(nil), cfg.Exclude...), c.Exclude...)
+
+	c.configApplied = true
+	return nil
+}
+
+// sourceSuffix 返回源文件后缀
+func (c *Compiler) sourceSuffix() string {
+	if c.SourceSuffix == "" {
+		return defaultSourceSuffix
+	}
+	return c.SourceSuffix
+}
+
+// outputSuffix 返回生成文件后缀
+func (c *Compiler) outputSuffix() string {
+	if c.OutputSuffix == "" {
+		return defaultOutputSuffix
+	}
+	return c.OutputSuffix
+}
+
+// prepare 应用项目配置、校验选项并将路径换算为绝对路径，所有入口在开始工作前调用
+func (c *Compiler) prepare() error {
+	if err := c.applyConfig(); err != nil {
+		return err
+	}
+
+	if !c.Layout.valid() {
+		return fmt.Errorf("未知的输出布局: %q", c.Layout)
+	}
+	if err := checkDialect(c.Dialect); err != nil {
+		return err
+	}
+	if !strings.HasSuffix(c.outputSuffix(), ".go") {
+		return fmt.Errorf("生成文件后缀必须以 .go 结尾: %q", c.outputSuffix())
+	}
+	if c.sourceSuffix() == c.outputSuffix() {
+		return fmt.Errorf("源文件后缀和生成文件后缀不能相同: %q", c.sourceSuffix())
+	}
+
+	return c.resolvePaths()
+}
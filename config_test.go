@@ -0,0 +1,84 @@
+package gox
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestApplyConfigBoolOptions(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"gox.toml": "smart_scope = true\nlenient = true\ntype_check = true\n",
+	})
+
+	tests := []struct {
+		name     string
+		c        Compiler
+		explicit Option
+		want     [3]bool // SmartScope, Lenient, TypeCheck
+	}{
+		{name: "from config", want: [3]bool{true, true, true}},
+		{name: "explicit false", explicit: OptSmartScope | OptTypeCheck, want: [3]bool{false, true, false}},
+		{name: "explicit lenient", explicit: OptLenient, want: [3]bool{true, false, true}},
+		{name: "set true", c: Compiler{Lenient: true}, explicit: OptLenient, want: [3]bool{true, true, true}},
+	}
+	for _, tt := range tests {
+		c := tt.c
+		c.SrcPath = dir
+		c.ConfigFile = filepath.Join(dir, "gox.toml")
+		c.Explicit = tt.explicit
+		if err := c.applyConfig(); err != nil {
+			t.Fatal(err)
+		}
+		if got := [3]bool{c.SmartScope, c.Lenient, c.TypeCheck}; got != tt.want {
+			t.Errorf("%s: SmartScope, Lenient, TypeCheck = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestApplyConfigPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"gox.json": `{"src": "dao", "dest": "out", "layout": "mirror", "header": "config", "exclude": ["a/"]}`,
+	})
+
+	c := &Compiler{SrcPath: filepath.Join(dir, "x"), Header: "compiler", Exclude: []string{"b/"}}
+	c.ConfigFile = filepath.Join(dir, "gox.json")
+	if err := c.applyConfig(); err != nil {
+		t.Fatal(err)
+	}
+	if c.SrcPath != filepath.Join(dir, "x") || c.DestPath != filepath.Join(dir, "out") {
+		t.Errorf("SrcPath, DestPath = %s, %s", c.SrcPath, c.DestPath)
+	}
+	if c.Layout != LayoutMirror || c.Header != "compiler" {
+		t.Errorf("Layout, Header = %s, %s", c.Layout, c.Header)
+	}
+	if len(c.Exclude) != 2 || c.Exclude[0] != "a/" || c.Exclude[1] != "b/" {
+		t.Errorf("Exclude = %v, want [a/ b/]", c.Exclude)
+	}
+}
+
+func TestDialect(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"gox.toml": "dialect = \"sqlite\"\n",
+		"pg.toml":  "dialect = \"postgres\"\n",
+	})
+
+	c := &Compiler{SrcPath: dir, ConfigFile: filepath.Join(dir, "gox.toml")}
+	if err := c.prepare(); err != nil {
+		t.Fatal(err)
+	}
+	if c.Dialect != DialectSQLite {
+		t.Errorf("Dialect = %q, want %q", c.Dialect, DialectSQLite)
+	}
+
+	c = &Compiler{SrcPath: dir, ConfigFile: filepath.Join(dir, "pg.toml")}
+	if err := c.prepare(); err == nil || !strings.Contains(err.Error(), "不支持的 SQL 方言") {
+		t.Errorf("prepare with postgres dialect: error = %v", err)
+	}
+	if _, err := CompileSource("a.gox.go", []byte(testSource("dao")), Options{Dialect: "postgres"}); err == nil {
+		t.Error("CompileSource accepted the postgres dialect")
+	}
+}
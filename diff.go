@@ -0,0 +1,220 @@
+package gox
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// diffContext unified diff 中每个变更块前后保留的上下文行数
+const diffContext = 3
+
+// maxDiffEdits Myers 算法搜索的最大编辑行数，超过后整体作为一个变更块输出
+// 回溯需要保存每一步的对角线，内存与编辑数的平方成正比，1000 行约为 8MB
+const maxDiffEdits = 1000
+
+// diffOp 行级差异操作
+type diffOp struct {
+	kind byte // ' ' 相同，'-' 删除，'+' 新增
+	line string
+}
+
+// unifiedDiff 生成两个文本的 unified diff，内容相同时返回空字符串
+func unifiedDiff(oldName, newName string, oldData, newData []byte) string {
+	if string(oldData) == string(newData) {
+		return ""
+	}
+
+	ops := diffLines(splitLines(string(oldData)), splitLines(string(newData)))
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", oldName, newName)
+
+	// 按变更位置切分为带上下文的块
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+
+		start := i - diffContext
+		if start < 0 {
+			start = 0
+		}
+		end := i
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+			// 连续相同的行超过两倍上下文时结束当前块
+			same := end
+			for same < len(ops) && ops[same].kind == ' ' {
+				same++
+			}
+			if same == len(ops) || same-end > 2*diffContext {
+				end += diffContext
+				if end > len(ops) {
+					end = len(ops)
+				}
+				break
+			}
+			end = same
+		}
+
+		writeHunk(&sb, ops, start, end)
+		i = end
+	}
+	return sb.String()
+}
+
+// writeHunk 输出 ops[start:end] 对应的变更块
+func writeHunk(sb *strings.Builder, ops []diffOp, start, end int) {
+	oldLine, newLine := 1, 1
+	for _, op := range ops[:start] {
+		if op.kind != '+' {
+			oldLine++
+		}
+		if op.kind != '-' {
+			newLine++
+		}
+	}
+
+	var oldCount, newCount int
+	for _, op := range ops[start:end] {
+		if op.kind != '+' {
+			oldCount++
+		}
+		if op.kind != '-' {
+			newCount++
+		}
+	}
+	if oldCount == 0 {
+		oldLine--
+	}
+	if newCount == 0 {
+		newLine--
+	}
+
+	fmt.Fprintf(sb, "@@ -%d,%d +%d,%d @@\n", oldLine, oldCount, newLine, newCount)
+	for _, op := range ops[start:end] {
+		sb.WriteByte(op.kind)
+		sb.WriteString(op.line)
+		sb.WriteByte('\n')
+	}
+}
+
+// diffLines 计算行级差异，公共前缀和后缀之外的部分使用 Myers 算法
+func diffLines(a, b []string) []diffOp {
+	// 去掉公共前缀和后缀，生成文件通常只有少量行变化
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var ops []diffOp
+	for _, line := range a[:prefix] {
+		ops = append(ops, diffOp{' ', line})
+	}
+
+	midA := a[prefix : len(a)-suffix]
+	midB := b[prefix : len(b)-suffix]
+	if mid, ok := myersDiff(midA, midB); ok {
+		ops = append(ops, mid...)
+	} else {
+		for _, line := range midA {
+			ops = append(ops, diffOp{'-', line})
+		}
+		for _, line := range midB {
+			ops = append(ops, diffOp{'+', line})
+		}
+	}
+
+	for _, line := range a[len(a)-suffix:] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	return ops
+}
+
+// myersDiff 使用 Myers O(ND) 算法计算 a 到 b 的最短编辑序列，编辑数超过 maxDiffEdits 时 ok 为 false
+func myersDiff(a, b []string) (ops []diffOp, ok bool) {
+	n, m := len(a), len(b)
+	limit := min(n+m, maxDiffEdits)
+
+	// v[off+k] 为对角线 k（x-y=k）上已到达的最远 x
+	off := limit + 1
+	v := make([]int, 2*limit+3)
+	// trace[d] 保存第 d 步开始前对角线 -d..d 的 v，用于回溯
+	var trace [][]int
+
+	for d := 0; d <= limit; d++ {
+		trace = append(trace, append([]int(nil), v[off-d:off+d+1]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[off+k-1] < v[off+k+1]) {
+				x = v[off+k+1] // 从对角线 k+1 下移：新增 b 中的一行
+			} else {
+				x = v[off+k-1] + 1 // 从对角线 k-1 右移：删除 a 中的一行
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[off+k] = x
+			if x >= n && y >= m {
+				return myersBacktrack(a, b, trace), true
+			}
+		}
+	}
+	return nil, false
+}
+
+// myersBacktrack 从终点沿 trace 回溯出编辑序列
+func myersBacktrack(a, b []string, trace [][]int) []diffOp {
+	var ops []diffOp
+	x, y := len(a), len(b)
+	for d := len(trace) - 1; d > 0; d-- {
+		prev := trace[d]
+		at := func(k int) int { return prev[k+d] }
+
+		k := x - y
+		prevK := k - 1
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			x--
+			y--
+			ops = append(ops, diffOp{' ', a[x]})
+		}
+		if prevK == k+1 {
+			y--
+			ops = append(ops, diffOp{'+', b[y]})
+		} else {
+			x--
+			ops = append(ops, diffOp{'-', a[x]})
+		}
+	}
+	for x > 0 {
+		x--
+		ops = append(ops, diffOp{' ', a[x]})
+	}
+	slices.Reverse(ops)
+	return ops
+}
+
+// splitLines 按行切分文本，末尾换行不产生空行
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
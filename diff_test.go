@@ -0,0 +1,156 @@
+package gox
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new string
+		want     string
+	}{
+		{
+			name: "same",
+			old:  "a\nb\n",
+			new:  "a\nb\n",
+			want: "",
+		},
+		{
+			name: "change",
+			old:  "a\nb\nc\n",
+			new:  "a\nB\nc\n",
+			want: "--- old\n+++ new\n@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n",
+		},
+		{
+			name: "insert at start",
+			old:  "b\n",
+			new:  "a\nb\n",
+			want: "--- old\n+++ new\n@@ -1,1 +1,2 @@\n+a\n b\n",
+		},
+		{
+			name: "delete all",
+			old:  "a\nb\n",
+			new:  "",
+			want: "--- old\n+++ new\n@@ -1,2 +0,0 @@\n-a\n-b\n",
+		},
+		{
+			name: "add to empty",
+			old:  "",
+			new:  "a\n",
+			want: "--- old\n+++ new\n@@ -0,0 +1,1 @@\n+a\n",
+		},
+		{
+			name: "separate hunks",
+			old:  "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n",
+			new:  "x\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\ny\n",
+			want: "--- old\n+++ new\n@@ -1,4 +1,4 @@\n-1\n+x\n 2\n 3\n 4\n@@ -9,4 +9,4 @@\n 9\n 10\n 11\n-12\n+y\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := unifiedDiff("old", "new", []byte(tt.old), []byte(tt.new))
+			if got != tt.want {
+				t.Errorf("unifiedDiff =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+// applyOps 用编辑序列还原出旧文本和新文本
+func applyOps(ops []diffOp) (a, b []string) {
+	for _, op := range ops {
+		if op.kind != '+' {
+			a = append(a, op.line)
+		}
+		if op.kind != '-' {
+			b = append(b, op.line)
+		}
+	}
+	return a, b
+}
+
+func TestDiffLinesMinimal(t *testing.T) {
+	tests := []struct {
+		a, b  string
+		edits int
+	}{
+		{"abcabba", "cbabac", 5},
+		{"abc", "abc", 0},
+		{"abc", "", 3},
+		{"", "abc", 3},
+		{"xaxbxc", "abc", 3},
+		{"abcdef", "abxdyf", 4},
+	}
+	for _, tt := range tests {
+		a, b := strings.Split(tt.a, ""), strings.Split(tt.b, "")
+		ops := diffLines(a, b)
+
+		gotA, gotB := applyOps(ops)
+		if strings.Join(gotA, "") != tt.a || strings.Join(gotB, "") != tt.b {
+			t.Errorf("%q -> %q: ops reproduce %q -> %q", tt.a, tt.b, strings.Join(gotA, ""), strings.Join(gotB, ""))
+		}
+		edits := 0
+		for _, op := range ops {
+			if op.kind != ' ' {
+				edits++
+			}
+		}
+		if edits != tt.edits {
+			t.Errorf("%q -> %q: %d edits, want %d", tt.a, tt.b, edits, tt.edits)
+		}
+	}
+}
+
+func TestDiffLinesTooManyEdits(t *testing.T) {
+	var a, b []string
+	for i := 0; i < maxDiffEdits; i++ {
+		a = append(a, fmt.Sprintf("a%d", i))
+		b = append(b, fmt.Sprintf("b%d", i))
+	}
+	a = append(a, "same")
+	b = append(b, "same")
+
+	ops := diffLines(a, b)
+	gotA, gotB := applyOps(ops)
+	if len(gotA) != len(a) || len(gotB) != len(b) {
+		t.Fatalf("ops reproduce %d/%d lines, want %d/%d", len(gotA), len(gotB), len(a), len(b))
+	}
+	for i, op := range ops[:maxDiffEdits] {
+		if op.kind != '-' {
+			t.Fatalf("op %d = %c, want all deletions first", i, op.kind)
+		}
+	}
+	if last := ops[len(ops)-1]; last.kind != ' ' || last.line != "same" {
+		t.Errorf("last op = %c%s, want common suffix", last.kind, last.line)
+	}
+}
+
+func TestCheckRestoresDryRun(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"dao/user.gox.go": testSource("dao")})
+	output := filepath.Join(dir, "dao", "user_gen.go")
+
+	c := newTestCompiler(filepath.Join(dir, "dao"))
+	var stale *StaleError
+	if err := c.Check(); !errors.As(err, &stale) {
+		t.Fatalf("Check before compiling: error = %v, want a *StaleError", err)
+	}
+	if c.DryRun || fileExists(output) {
+		t.Fatalf("Check left DryRun = %v, output exists = %v", c.DryRun, fileExists(output))
+	}
+
+	if err := c.Compile(); err != nil {
+		t.Fatal(err)
+	}
+	if !fileExists(output) {
+		t.Fatal("Compile after Check wrote nothing")
+	}
+	if err := c.Check(); err != nil {
+		t.Errorf("Check after compiling: %v", err)
+	}
+}
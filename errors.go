@@ -0,0 +1,94 @@
+package gox
+
+import (
+	"fmt"
+	"go/token"
+	"sort"
+	"strings"
+)
+
+// FileError 单个文件的编译错误
+type FileError struct {
+	File string // 出错的源文件路径
+	Err  error  // 原始错误
+}
+
+func (e *FileError) Error() string {
+	return fmt.Sprintf("%s: %v", e.File, e.Err)
+}
+
+func (e *FileError) Unwrap() error {
+	return e.Err
+}
+
+// CompileErrors 一次编译中所有失败文件的错误集合，按文件路径排序
+type CompileErrors []*FileError
+
+func (e CompileErrors) Error() string {
+	if len(e) == 1 {
+		return e[0].Error()
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%d 个文件编译失败:", len(e)))
+	for _, fe := range e {
+		sb.WriteString("\n")
+		sb.WriteString(fe.Error())
+	}
+	return sb.String()
+}
+
+// Unwrap 支持 errors.Is / errors.As 检查其中的每个错误
+func (e CompileErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, fe := range e {
+		errs[i] = fe
+	}
+	return errs
+}
+
+// errorOrNil 排序后返回错误集合，没有错误时返回 nil
+func (e CompileErrors) errorOrNil() error {
+	if len(e) == 0 {
+		return nil
+	}
+	sort.Slice(e, func(i, j int) bool { return e[i].File < e[j].File })
+	return e
+}
+
+// StaleError DryRun 模式下目标文件缺失或内容与生成结果不一致
+type StaleError struct {
+	Output  string // 目标文件路径
+	Missing bool   // 目标文件不存在
+	Diff    string // 现有文件与生成结果的 unified diff
+}
+
+func (e *StaleError) Error() string {
+	if e.Missing {
+		return fmt.Sprintf("目标文件不存在: %s", e.Output)
+	}
+	return fmt.Sprintf("目标文件已过期: %s", e.Output)
+}
+
+// TypeError 生成代码的类型检查错误，启用 //line 指令时位置指向 .gox.go 源文件
+type TypeError struct {
+	Pos token.Position // 错误位置
+	Msg string         // 错误信息
+}
+
+func (e *TypeError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Pos, e.Msg)
+}
+
+// TypeErrors 一个源文件的所有类型检查错误，按位置排序
+type TypeErrors []*TypeError
+
+func (e TypeErrors) Error() string {
+	var sb strings.Builder
+	sb.WriteString("类型检查失败:")
+	for _, te := range e {
+		sb.WriteString("\n\t")
+		sb.WriteString(te.Error())
+	}
+	return sb.String()
+}
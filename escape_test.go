@@ -0,0 +1,81 @@
+package gox
+
+import (
+	"go/ast"
+	goparser "go/parser"
+	"go/token"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// staticSQL 按源码顺序拼接生成代码中 AddText 的字符串常量，AddParam 记为 ?，空白归一为单个空格
+// 条件分支中的文本也会拼接进来，用于检查模板文本的生成结果
+func staticSQL(t *testing.T, code []byte) string {
+	t.Helper()
+	file, err := goparser.ParseFile(token.NewFileSet(), "", code, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var parts []string
+	ast.Inspect(file, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || len(call.Args) != 1 {
+			return true
+		}
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		switch sel.Sel.Name {
+		case "AddParam":
+			parts = append(parts, "?")
+		case "AddText":
+			if lit, ok := call.Args[0].(*ast.BasicLit); ok && lit.Kind == token.STRING {
+				s, err := strconv.Unquote(lit.Value)
+				if err != nil {
+					t.Fatal(err)
+				}
+				parts = append(parts, s)
+			}
+		}
+		return true
+	})
+	return strings.Join(strings.Fields(strings.Join(parts, "")), " ")
+}
+
+func TestEscapes(t *testing.T) {
+	tests := []struct {
+		name  string
+		smart bool
+		sql   string
+		want  string
+	}{
+		{name: "at", sql: `select 'a\@b.com'`, want: `select 'a@b.com'`},
+		{name: "operator", sql: `select * from t where tags \@> #{id}`, want: `select * from t where tags @> ?`},
+		{name: "hash", sql: `select \#{id}, #{id}`, want: `select #{id}, ?`},
+		{name: "dollar", sql: `select \${id}, \$1`, want: `select ${id}, $1`},
+		{name: "braces", sql: `select '\{"k":1\}'`, want: `select '{"k":1}'`},
+		{name: "other backslash", sql: `select 'a\nb', 'c\\d'`, want: `select 'a\nb', 'c\\d'`},
+		{name: "at line", sql: "select 1\n@and a = 'x\\@y' and b = \\#{id} and c = #{id}", want: `select 1 and a = 'x@y' and b = #{id} and c = ?`},
+		{name: "code block", sql: "select 1\n{\n\tif id > 0 {\n\t\t@and a = '\\@' and b = '\\{\\}' and c = #{id}\n\t}\n}", want: `select 1 and a = '@' and b = '{}' and c = ?`},
+		{name: "at block", sql: `select 1 @{ and e = 'p\@q' \$ \{ \} #{id} }`, want: `select 1 and e = 'p@q' $ { } ?`},
+		{name: "smart plain", smart: true, sql: `select '\@', \#{id}, '\{\}' from t`, want: `select '@', #{id}, '{}' from t`},
+		{name: "smart at block", smart: true, sql: `select 1 @{ and e = 'p\@q' \$ \{ \} }`, want: `select 1 and e = 'p@q' $ { }`},
+		{name: "smart code block", smart: true, sql: "select 1\n{\n\tif id > 0 {\n\t\t@and a = '\\@' and b = \\${id} and c = #{id}\n\t}\n}", want: `select 1 and a = '@' and b = ${id} and c = ?`},
+		{name: "smart multiline at", smart: true, sql: "select 1\n@and id in (\n\t#{id}, '\\@', '\\{'\n)", want: `select 1 and id in (?, '@', '{' )`},
+	}
+	for _, tt := range tests {
+		src := "package dao\n\nimport \"github.com/llyb120/gox\"\n\nfunc Find(id int) gox.Query {\n\treturn gox.Sql(`\n" +
+			tt.sql + "\n`)\n}\n"
+		code, err := CompileSource("a.gox.go", []byte(src), Options{SmartScope: tt.smart, NoLineDirectives: true})
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got := staticSQL(t, code); got != tt.want {
+			t.Errorf("%s: SQL = %s, want %s\n%s", tt.name, got, tt.want, code)
+		}
+	}
+}
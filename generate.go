@@ -0,0 +1,49 @@
+package gox
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// InGoGenerate 判断当前进程是否由 go generate 启动（设置了 GOFILE 和 GOPACKAGE 环境变量）
+func InGoGenerate() bool {
+	return os.Getenv("GOFILE") != "" && os.Getenv("GOPACKAGE") != ""
+}
+
+// NewGenerateCompiler 创建供 //go:generate 使用的编译器
+// go generate 在包含指令的包目录下执行命令，编译器只处理该目录中的 .gox.go 文件，
+// 不进入子目录，未设置 DestPath 时生成文件写在源文件旁边；在 go generate 之外调用时返回错误
+func NewGenerateCompiler() (*Compiler, error) {
+	if !InGoGenerate() {
+		return nil, errors.New("未在 go generate 中运行: 缺少 GOFILE 或 GOPACKAGE 环境变量")
+	}
+	c := &Compiler{}
+	if err := c.SetupGenerate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// SetupGenerate 把编译器设置为 go generate 模式：只编译包目录中的 .gox.go 文件，不进入子目录；
+// SrcPath 为空时使用当前目录，即 go generate 中的包目录
+// 布局和输出目录与普通编译相同：未设置 DestPath 时生成文件写在源文件旁边，设置后默认平铺到 DestPath
+func (c *Compiler) SetupGenerate() error {
+	if c.SrcPath == "" {
+		dir, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+		// GOFILE 为包含 //go:generate 指令的文件名，相对包目录
+		if InGoGenerate() {
+			if _, err := os.Stat(filepath.Join(dir, os.Getenv("GOFILE"))); err != nil {
+				return fmt.Errorf("包 %s 的目录 %s 中找不到 %s: %v", os.Getenv("GOPACKAGE"), dir, os.Getenv("GOFILE"), err)
+			}
+		}
+		c.SrcPath = dir
+	}
+
+	c.NonRecursive = true
+	return nil
+}
@@ -0,0 +1,277 @@
+package gox
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ignoreFile 目录编译时读取的忽略规则文件名，语法与 .gitignore 相同
+const ignoreFile = ".goxignore"
+
+// defaultIgnoredDirs 目录编译时默认跳过的目录，以 . 开头的隐藏目录也会被跳过
+var defaultIgnoredDirs = map[string]bool{
+	"vendor":       true,
+	"testdata":     true,
+	"node_modules": true,
+}
+
+// ignoreRule 一条 gitignore 风格的规则
+type ignoreRule struct {
+	re      *regexp.Regexp
+	negate  bool // 以 ! 开头，重新包含之前被忽略的路径
+	dirOnly bool // 以 / 结尾，只匹配目录
+}
+
+// ignoreList 一个目录下的忽略规则，规则中的路径相对该目录
+type ignoreList struct {
+	dir   string
+	rules []ignoreRule
+}
+
+// match 返回最后一条匹配 path 的规则的结果，没有规则匹配时 matched 为 false
+func (l *ignoreList) match(path string, isDir bool) (ignored, matched bool) {
+	rel, err := filepath.Rel(l.dir, path)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return false, false
+	}
+	rel = filepath.ToSlash(rel)
+
+	// 只匹配目录的规则对文件而言匹配其所在的目录，使 dao/ 这样的规则也作用于目录下的文件
+	parent := ""
+	if i := strings.LastIndexByte(rel, '/'); i != -1 {
+		parent = rel[:i]
+	}
+	for _, rule := range l.rules {
+		target := rel
+		if rule.dirOnly && !isDir {
+			if parent == "" {
+				continue
+			}
+			target = parent
+		}
+		if rule.re.MatchString(target) {
+			ignored, matched = !rule.negate, true
+		}
+	}
+	return ignored, matched
+}
+
+// parseIgnoreRules 解析 gitignore 语法的规则
+func parseIgnoreRules(lines []string) ([]ignoreRule, error) {
+	var rules []ignoreRule
+	for _, line := range lines {
+		line = strings.TrimRight(line, "\r")
+		// 行尾空格被忽略，除非使用 \ 转义
+		for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, "\\ ") {
+			line = line[:len(line)-1]
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var rule ignoreRule
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		} else if strings.HasPrefix(line, "\\!") || strings.HasPrefix(line, "\\#") {
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+
+		re, err := globRegexp(line)
+		if err != nil {
+			return nil, fmt.Errorf("无效的匹配模式 %q: %v", line, err)
+		}
+		rule.re = re
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// globRegexp 将 gitignore 风格的模式转换为正则表达式
+// 不含 / 的模式匹配任意层级的文件名，含 / 的模式相对规则所在目录；
+// * 和 ? 不匹配 /，** 匹配任意层级的目录
+func globRegexp(pattern string) (*regexp.Regexp, error) {
+	var sb strings.Builder
+	sb.WriteString("^")
+
+	if !strings.Contains(pattern, "/") {
+		sb.WriteString("(?:.*/)?")
+	}
+	pattern = strings.TrimPrefix(pattern, "/")
+
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			sb.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			sb.WriteString(".*")
+			i++
+		case c == '*':
+			sb.WriteString("[^/]*")
+		case c == '?':
+			sb.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end == -1 {
+				sb.WriteString(regexp.QuoteMeta("["))
+				continue
+			}
+			class := pattern[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			sb.WriteString("[" + strings.ReplaceAll(class, "/", "") + "]")
+			i += end + 1
+		case c == '\\' && i+1 < len(pattern):
+			i++
+			sb.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		default:
+			sb.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		}
+	}
+
+	// 匹配目录时同时匹配目录下的所有文件
+	sb.WriteString("(?:/.*)?$")
+	return regexp.Compile(sb.String())
+}
+
+// sourceFilter 目录编译时决定哪些源文件参与编译
+// 依次应用默认跳过的目录、Exclude、各级目录下的 .goxignore 和 Include
+type sourceFilter struct {
+	root    string
+	include []ignoreRule
+	exclude *ignoreList
+	lists   map[string]*ignoreList // 目录 -> 该目录下 .goxignore 的规则
+	entered map[string]bool        // 已经读取过 .goxignore 的目录
+
+	readFile func(name string) ([]byte, error) // 读取 .goxignore，默认读取磁盘文件
+}
+
+// newSourceFilter 创建以 root 为根目录的源文件过滤器
+func (c *Compiler) newSourceFilter(root string) (*sourceFilter, error) {
+	f := &sourceFilter{
+		root:     root,
+		lists:    make(map[string]*ignoreList),
+		entered:  make(map[string]bool),
+		readFile: os.ReadFile,
+	}
+
+	include, err := parseIgnoreRules(c.Include)
+	if err != nil {
+		return nil, fmt.Errorf("Include 参数错误: %v", err)
+	}
+	f.include = include
+
+	exclude, err := parseIgnoreRules(c.Exclude)
+	if err != nil {
+		return nil, fmt.Errorf("Exclude 参数错误: %v", err)
+	}
+	f.exclude = &ignoreList{dir: root, rules: exclude}
+	return f, nil
+}
+
+// enterDir 读取目录下的 .goxignore，需要在访问目录中的文件之前调用，同一目录只读取一次
+func (f *sourceFilter) enterDir(dir string) error {
+	if f.entered[dir] {
+		return nil
+	}
+	f.entered[dir] = true
+
+	data, err := f.readFile(filepath.Join(dir, ignoreFile))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	rules, err := parseIgnoreRules(strings.Split(string(data), "\n"))
+	if err != nil {
+		return fmt.Errorf("%s: %v", filepath.Join(dir, ignoreFile), err)
+	}
+	if len(rules) > 0 {
+		f.lists[dir] = &ignoreList{dir: dir, rules: rules}
+	}
+	return nil
+}
+
+// skip 判断路径是否被排除，根目录本身永远不会被排除
+func (f *sourceFilter) skip(path string, isDir bool) bool {
+	if path == f.root {
+		return false
+	}
+
+	if isDir {
+		name := filepath.Base(path)
+		if strings.HasPrefix(name, ".") || defaultIgnoredDirs[name] {
+			return true
+		}
+	}
+
+	ignored, _ := f.exclude.match(path, isDir)
+
+	// 由上到下应用各级 .goxignore，深层目录的规则优先
+	var dirs []string
+	for dir := filepath.Dir(path); ; dir = filepath.Dir(dir) {
+		dirs = append(dirs, dir)
+		if dir == f.root || dir == filepath.Dir(dir) {
+			break
+		}
+	}
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if list, ok := f.lists[dirs[i]]; ok {
+			if ign, matched := list.match(path, isDir); matched {
+				ignored = ign
+			}
+		}
+	}
+	if ignored {
+		return true
+	}
+
+	// Include 只作用于文件，目录总是继续遍历
+	if !isDir && len(f.include) > 0 {
+		included := &ignoreList{dir: f.root, rules: f.include}
+		if inc, _ := included.match(path, false); !inc {
+			return true
+		}
+	}
+	return false
+}
+
+// selects 判断 path 是否是目录编译会选中的源文件：位于根目录下，沿途各级目录和文件本身都没有被排除
+// 沿途目录的 .goxignore 按需读取，读取失败时视为未选中
+func (f *sourceFilter) selects(path string) bool {
+	rel, err := filepath.Rel(f.root, path)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return false
+	}
+
+	dir := f.root
+	if err := f.enterDir(dir); err != nil {
+		return false
+	}
+	if sub := filepath.Dir(rel); sub != "." {
+		for _, name := range strings.Split(sub, string(filepath.Separator)) {
+			dir = filepath.Join(dir, name)
+			if f.skip(dir, true) || f.enterDir(dir) != nil {
+				return false
+			}
+		}
+	}
+	return !f.skip(path, false)
+}
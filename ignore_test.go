@@ -0,0 +1,138 @@
+package gox
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestGlobRegexp(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"user.gox.go", "user.gox.go", true},
+		{"user.gox.go", "dao/user.gox.go", true},
+		{"user.gox.go", "dao/user.gox.go.bak", false},
+		{"*.gox.go", "a/b/c.gox.go", true},
+		{"*.gox.go", "c.go", false},
+		{"dao/*.gox.go", "dao/user.gox.go", true},
+		{"dao/*.gox.go", "dao/sub/user.gox.go", false},
+		{"dao/*.gox.go", "x/dao/user.gox.go", false},
+		{"/dao", "dao", true},
+		{"/dao", "dao/user.gox.go", true},
+		{"/dao", "x/dao", false},
+		{"**/gen", "gen", true},
+		{"**/gen", "a/b/gen/x.gox.go", true},
+		{"dao/**", "dao/a/b.gox.go", true},
+		{"dao/**/user.gox.go", "dao/user.gox.go", true},
+		{"dao/**/user.gox.go", "dao/a/b/user.gox.go", true},
+		{"a?c", "abc", true},
+		{"a?c", "a/c", false},
+		{"[ab].go", "b.go", true},
+		{"[!ab].go", "b.go", false},
+		{"[!ab].go", "c.go", true},
+		{"[ab", "[ab", true},
+		{`\*.go`, "*.go", true},
+		{`\*.go`, "a.go", false},
+		{"a.b", "axb", false},
+		{"a+b", "a+b", true},
+	}
+	for _, tt := range tests {
+		re, err := globRegexp(tt.pattern)
+		if err != nil {
+			t.Errorf("globRegexp(%q): %v", tt.pattern, err)
+			continue
+		}
+		if got := re.MatchString(tt.path); got != tt.want {
+			t.Errorf("globRegexp(%q) matches %q = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestParseIgnoreRules(t *testing.T) {
+	rules, err := parseIgnoreRules([]string{
+		"# 注释",
+		"",
+		"gen/",
+		"!keep.gox.go",
+		`\!bang.gox.go`,
+		`\#hash.gox.go`,
+		"trailing.gox.go   ",
+		`space\ `,
+		"crlf.gox.go\r",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	list := &ignoreList{dir: filepath.FromSlash("/root"), rules: rules}
+	tests := []struct {
+		path           string
+		isDir          bool
+		ignored, match bool
+	}{
+		{"gen", true, true, true},
+		{"gen", false, false, false},
+		{"gen/a.gox.go", false, true, true},
+		{"x/gen/sub/a.gox.go", false, true, true},
+		{"keep.gox.go", false, false, true},
+		{"!bang.gox.go", false, true, true},
+		{"#hash.gox.go", false, true, true},
+		{"trailing.gox.go", false, true, true},
+		{"space ", false, true, true},
+		{"crlf.gox.go", false, true, true},
+		{"other.gox.go", false, false, false},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(list.dir, filepath.FromSlash(tt.path))
+		ignored, matched := list.match(path, tt.isDir)
+		if ignored != tt.ignored || matched != tt.match {
+			t.Errorf("match(%q, dir=%v) = %v, %v, want %v, %v", tt.path, tt.isDir, ignored, matched, tt.ignored, tt.match)
+		}
+	}
+}
+
+func TestSourceFilterSelects(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		".goxignore":     "*.skip.gox.go\nlegacy/\n",
+		"dao/.goxignore": "!keep.skip.gox.go\n",
+	})
+
+	c := &Compiler{Exclude: []string{"tmp/"}, Include: []string{"dao/", "*.only.gox.go"}}
+	f, err := c.newSourceFilter(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"dao/user.gox.go", true},
+		{"dao/a.skip.gox.go", false},
+		{"dao/keep.skip.gox.go", true},
+		{"legacy/dao/user.gox.go", false},
+		{"tmp/dao/user.gox.go", false},
+		{"vendor/dao/user.gox.go", false},
+		{".cache/dao/user.gox.go", false},
+		{"other/user.gox.go", false},
+		{"other/user.only.gox.go", true},
+	}
+	for _, tt := range tests {
+		if got := f.selects(filepath.Join(dir, filepath.FromSlash(tt.path))); got != tt.want {
+			t.Errorf("selects(%s) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+	if f.selects(filepath.Join(filepath.Dir(dir), "outside.gox.go")) {
+		t.Error("selects a file outside the root")
+	}
+}
+
+func TestInvalidIgnorePattern(t *testing.T) {
+	c := &Compiler{Exclude: []string{"[z-a]"}}
+	if _, err := c.newSourceFilter(t.TempDir()); err == nil {
+		t.Error("newSourceFilter accepted an invalid pattern")
+	}
+}
@@ -0,0 +1,100 @@
+package gox
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Layout 生成文件的输出布局
+type Layout string
+
+const (
+	// LayoutFlat 所有生成文件平铺输出到 DestPath（设置了 DestPath 时的默认布局）
+	LayoutFlat Layout = "flat"
+	// LayoutMirror 在 DestPath 下保持源文件相对 SrcPath 的目录结构
+	LayoutMirror Layout = "mirror"
+	// LayoutAlongside 生成文件写在对应的 .gox.go 源文件旁边，忽略 DestPath（没有设置 DestPath 时的默认布局）
+	LayoutAlongside Layout = "alongside"
+)
+
+// valid 检查布局是否合法，空值按是否设置了 DestPath 取默认布局
+func (l Layout) valid() bool {
+	switch l {
+	case "", LayoutFlat, LayoutMirror, LayoutAlongside:
+		return true
+	}
+	return false
+}
+
+// outputPath 返回 .gox.go 文件对应的目标文件路径
+func (c *Compiler) outputPath(goxPath string) string {
+	fileName := filepath.Base(goxPath)
+	fileName = strings.TrimSuffix(fileName, c.sourceSuffix()) + c.outputSuffix()
+
+	// overlay 模式在缓存目录下保持源文件的目录结构
+	if c.overlayMode() {
+		rel, err := filepath.Rel(c.srcRoot(), filepath.Dir(goxPath))
+		if err == nil && !strings.HasPrefix(rel, "..") {
+			return filepath.Join(c.CacheDir, rel, fileName)
+		}
+		return filepath.Join(c.CacheDir, fileName)
+	}
+
+	switch c.Layout {
+	case LayoutAlongside:
+		return filepath.Join(filepath.Dir(goxPath), fileName)
+	case LayoutMirror:
+		rel, err := filepath.Rel(c.srcRoot(), filepath.Dir(goxPath))
+		if err == nil && !strings.HasPrefix(rel, "..") {
+			return filepath.Join(c.DestPath, rel, fileName)
+		}
+		// 不在 SrcPath 下的文件（例如单独编译的文件）退化为平铺
+	}
+	return filepath.Join(c.DestPath, fileName)
+}
+
+// srcRoot 返回源文件根目录，SrcPath 为单个文件时取其所在目录
+func (c *Compiler) srcRoot() string {
+	if info, err := os.Stat(c.SrcPath); err == nil && !info.IsDir() {
+		return filepath.Dir(c.SrcPath)
+	}
+	return c.SrcPath
+}
+
+// outputRoot 返回生成文件所在的根目录
+func (c *Compiler) outputRoot() string {
+	if c.overlayMode() {
+		return c.CacheDir
+	}
+	if c.Layout == LayoutAlongside {
+		return c.srcRoot()
+	}
+	return c.DestPath
+}
+
+// outputConflicts 检查是否有多个源文件映射到同一个目标文件，返回冲突的文件错误
+func (c *Compiler) outputConflicts(files []string) CompileErrors {
+	sources := make(map[string][]string)
+	for _, file := range files {
+		goPath := c.outputPath(file)
+		sources[goPath] = append(sources[goPath], file)
+	}
+
+	var errs CompileErrors
+	for goPath, files := range sources {
+		if len(files) < 2 {
+			continue
+		}
+		for _, file := range files {
+			errs = append(errs, &FileError{
+				File: file,
+				Err:  fmt.Errorf("输出文件冲突: %d 个源文件都会生成 %s", len(files), goPath),
+			})
+		}
+	}
+	sort.Slice(errs, func(i, j int) bool { return errs[i].File < errs[j].File })
+	return errs
+}
@@ -0,0 +1,115 @@
+package gox
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeFiles 在 dir 下按相对路径写入测试文件
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// testSource 返回包名为 pkg 的最小 .gox.go 源文件
+func testSource(pkg string) string {
+	return "//go:build ignore\n\npackage " + pkg + "\n\nimport \"github.com/llyb120/gox\"\n\n" +
+		"func Find(id int) gox.Query {\n\treturn gox.Sql(`select * from t where id = #{id}`)\n}\n"
+}
+
+// newTestCompiler 返回不读取配置文件、不输出进度的编译器
+func newTestCompiler(src string) *Compiler {
+	return &Compiler{SrcPath: src, NoConfig: true, Reporter: QuietReporter{}}
+}
+
+func TestOutputPath(t *testing.T) {
+	src := filepath.FromSlash("/work/dao")
+	dest := filepath.FromSlash("/work/out")
+	file := filepath.Join(src, "sub", "user.gox.go")
+
+	tests := []struct {
+		layout Layout
+		want   string
+	}{
+		{LayoutFlat, filepath.Join(dest, "user_gen.go")},
+		{LayoutMirror, filepath.Join(dest, "sub", "user_gen.go")},
+		{LayoutAlongside, filepath.Join(src, "sub", "user_gen.go")},
+	}
+	for _, tt := range tests {
+		c := &Compiler{SrcPath: src, DestPath: dest, Layout: tt.layout}
+		if got := c.outputPath(file); got != tt.want {
+			t.Errorf("layout %s: outputPath = %s, want %s", tt.layout, got, tt.want)
+		}
+	}
+}
+
+func TestDefaultLayoutWithoutDest(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"dao/user.gox.go":     testSource("dao"),
+		"dao/sub/user.gox.go": testSource("sub"),
+	})
+
+	c := newTestCompiler(filepath.Join(dir, "dao"))
+	if err := c.Compile(); err != nil {
+		t.Fatal(err)
+	}
+	if c.Layout != LayoutAlongside {
+		t.Errorf("Layout = %q, want %q", c.Layout, LayoutAlongside)
+	}
+	for _, name := range []string{"dao/user_gen.go", "dao/sub/user_gen.go"} {
+		if !fileExists(filepath.Join(dir, filepath.FromSlash(name))) {
+			t.Errorf("%s was not generated", name)
+		}
+	}
+}
+
+func TestFlatLayoutWithDest(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"dao/user.gox.go": testSource("dao")})
+
+	c := newTestCompiler(filepath.Join(dir, "dao"))
+	c.DestPath = filepath.Join(dir, "out")
+	if err := c.Compile(); err != nil {
+		t.Fatal(err)
+	}
+	if !fileExists(filepath.Join(dir, "out", "user_gen.go")) {
+		t.Error("out/user_gen.go was not generated")
+	}
+}
+
+func TestSetupGenerateLayout(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"dao/user.gox.go":     testSource("dao"),
+		"dao/sub/user.gox.go": testSource("sub"),
+	})
+
+	c := newTestCompiler(filepath.Join(dir, "dao"))
+	if err := c.SetupGenerate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Compile(); err != nil {
+		t.Fatal(err)
+	}
+	checkExists(t, dir, map[string]bool{"dao/user_gen.go": true, "dao/sub/user_gen.go": false})
+
+	c = newTestCompiler(filepath.Join(dir, "dao"))
+	c.DestPath = filepath.Join(dir, "out")
+	if err := c.SetupGenerate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Compile(); err != nil {
+		t.Fatal(err)
+	}
+	checkExists(t, dir, map[string]bool{"out/user_gen.go": true, "out/sub/user_gen.go": false})
+}
@@ -0,0 +1,114 @@
+package gox
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// lineSource 包含文档注释、代码块中的注释行以及多处未定义的标识符
+const lineSource = "//go:build ignore\n" +
+	"\n" +
+	"package dao\n" +
+	"\n" +
+	"import (\n" +
+	"\t\"fmt\"\n" +
+	"\n" +
+	"\t\"github.com/llyb120/gox\"\n" +
+	")\n" +
+	"\n" +
+	"// Find 查询\n" +
+	"// 第二行注释\n" +
+	"func Find(id int) gox.Query {\n" +
+	"\t_ = fmt.Sprint(id)\n" +
+	"\treturn gox.Sql(`\n" +
+	"\t\tselect * from t\n" +
+	"\t\t{\n" +
+	"\t\t\t// 注释行\n" +
+	"\t\t\tif missingA > 0 {\n" +
+	"\t\t\t\t// another comment\n" +
+	"\t\t\t\t@and id = #{missingE}\n" +
+	"\t\t\t}\n" +
+	"\t\t\ts := missingB\n" +
+	"\t\t\t_ = s\n" +
+	"\t\t}\n" +
+	"\t`)\n" +
+	"}\n" +
+	"\n" +
+	"func Other() {\n" +
+	"\tx := missingC\n" +
+	"\t_ = x\n" +
+	"}\n" +
+	"\n" +
+	"// Third 文档\n" +
+	"func Third() {\n" +
+	"\t// 注释\n" +
+	"\ty := missingD\n" +
+	"\t_ = y\n" +
+	"}\n"
+
+func TestLineDirectivesFormatting(t *testing.T) {
+	out, err := CompileSource("a.gox.go", []byte(lineSource), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := string(out)
+
+	// 文档注释保持原样，不被并入 //line 指令
+	for _, doc := range []string{"// Find 查询\n// 第二行注释\nfunc Find", "// Third 文档\nfunc Third"} {
+		if !strings.Contains(code, doc) {
+			t.Errorf("doc comment changed, want %q in\n%s", doc, code)
+		}
+	}
+	if strings.Contains(code, "\n//\n") {
+		t.Errorf("generated code contains an empty // line:\n%s", code)
+	}
+	if strings.Contains(code, ")\n//line") {
+		t.Errorf("line directive left after the import block:\n%s", code)
+	}
+}
+
+func TestLineDirectivesErrorPositions(t *testing.T) {
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go command not found")
+	}
+	repo, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := CompileSource("a.gox.go", []byte(lineSource), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod": "module linetest\n\ngo 1.24\n\nrequire github.com/llyb120/gox v0.0.0\n\n" +
+			"replace github.com/llyb120/gox => " + filepath.ToSlash(repo) + "\n",
+		"dao/a.gox.go": lineSource,
+		"dao/a_gen.go": string(out),
+	})
+
+	cmd := exec.Command(goBin, "build", "./...")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod", "GOPROXY=off", "GOWORK=off")
+	output, err := cmd.CombinedOutput()
+	if err == nil {
+		t.Fatal("go build succeeded, want undefined identifiers")
+	}
+
+	for _, want := range []string{
+		"a.gox.go:19:7: undefined: missingA",
+		"a.gox.go:21:17: undefined: missingE",
+		"a.gox.go:23:9: undefined: missingB",
+		"a.gox.go:30:7: undefined: missingC",
+		"a.gox.go:37:7: undefined: missingD",
+	} {
+		if !strings.Contains(string(output), want) {
+			t.Errorf("go build output missing %q:\n%s", want, output)
+		}
+	}
+}
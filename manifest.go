@@ -0,0 +1,168 @@
+package gox
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// manifestFile 增量编译清单的文件名，保存在输出根目录下
+const manifestFile = ".gox-manifest.json"
+
+// manifest 增量编译清单，记录每个源文件的内容哈希以及生成结果
+// 只有 gox 版本、编译选项、源文件哈希、目标文件哈希全部一致时才跳过编译
+type manifest struct {
+	Version string                    `json:"version"` // 生成清单时的 gox 版本
+	Options string                    `json:"options"` // 影响生成结果的编译选项
+	Files   map[string]*manifestEntry `json:"files"`   // 源文件路径（相对清单目录）-> 记录
+
+	mu       sync.Mutex
+	path     string // 清单文件的绝对路径
+	dirty    bool   // 是否有未保存的修改
+	outdated bool   // 清单由其他版本或编译选项生成，记录不能用于跳过编译
+}
+
+// manifestEntry 单个源文件的编译记录
+type manifestEntry struct {
+	Output     string `json:"output"`      // 目标文件路径（相对清单目录）
+	SourceHash string `json:"source_hash"` // 源文件内容哈希
+	OutputHash string `json:"output_hash"` // 目标文件内容哈希，用于发现被删除或手动修改的目标文件
+}
+
+// loadManifest 读取清单文件，版本或编译选项不一致时返回空清单
+func loadManifest(dir string, options string) (*manifest, error) {
+	saved, err := readManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &manifest{
+		Version: Version,
+		Options: options,
+		Files:   saved.Files,
+		path:    saved.path,
+		dirty:   saved.dirty,
+	}
+	// 版本或编译选项变化时保留记录用于清理孤立文件，但不再据此跳过编译
+	if saved.Version != Version || saved.Options != options {
+		m.outdated = true
+		m.dirty = true
+	}
+	return m, nil
+}
+
+// readManifest 原样读取清单文件，不检查版本和编译选项；文件不存在或损坏时返回空清单
+func readManifest(dir string) (*manifest, error) {
+	m := &manifest{
+		Files: make(map[string]*manifestEntry),
+		path:  filepath.Join(dir, manifestFile),
+	}
+
+	data, err := os.ReadFile(m.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return m, nil
+		}
+		return nil, fmt.Errorf("读取清单文件失败 %s: %v", m.path, err)
+	}
+
+	if err := json.Unmarshal(data, m); err != nil || m.Files == nil {
+		// 清单损坏时当作不存在
+		return &manifest{Files: make(map[string]*manifestEntry), path: m.path, dirty: true}, nil
+	}
+	return m, nil
+}
+
+// upToDate 检查源文件是否无需重新编译
+func (m *manifest) upToDate(srcPath, destPath string, srcHash string) bool {
+	m.mu.Lock()
+	entry, ok := m.Files[m.key(srcPath)]
+	outdated := m.outdated
+	m.mu.Unlock()
+
+	if !ok || outdated || entry.SourceHash != srcHash || entry.Output != m.key(destPath) {
+		return false
+	}
+
+	// 目标文件被删除或被手动修改时需要重新生成
+	data, err := os.ReadFile(destPath)
+	if err != nil {
+		return false
+	}
+	return hashBytes(data) == entry.OutputHash
+}
+
+// record 记录一次成功的编译
+func (m *manifest) record(srcPath, destPath string, srcHash string, output []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Files[m.key(srcPath)] = &manifestEntry{
+		Output:     m.key(destPath),
+		SourceHash: srcHash,
+		OutputHash: hashBytes(output),
+	}
+	m.dirty = true
+}
+
+// forget 删除源文件的编译记录，下次编译时会重新生成
+func (m *manifest) forget(srcPath string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.Files[m.key(srcPath)]; ok {
+		delete(m.Files, m.key(srcPath))
+		m.dirty = true
+	}
+}
+
+// save 将清单写回磁盘，没有修改时不写入
+func (m *manifest) save() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.dirty {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	if _, err := writeFileAtomic(m.path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("写入清单文件失败 %s: %v", m.path, err)
+	}
+	m.dirty = false
+	return nil
+}
+
+// key 将路径转换为相对清单目录的路径，使清单在不同机器上可复用
+func (m *manifest) key(path string) string {
+	rel, err := filepath.Rel(filepath.Dir(m.path), path)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// abs 将清单中的相对路径还原为绝对路径
+func (m *manifest) abs(key string) string {
+	return filepath.Join(filepath.Dir(m.path), filepath.FromSlash(key))
+}
+
+// hashBytes 计算内容的 sha256 哈希
+func hashBytes(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// manifestOptions 返回影响生成结果的编译选项，选项变化时清单失效
+func (c *Compiler) manifestOptions() string {
+	layout := c.Layout
+	if layout == "" {
+		layout = LayoutFlat
+	}
+	return fmt.Sprintf("layout=%s,line=%t,smart_scope=%t,lenient=%t,header=%q,stamp=%q",
+		layout, !c.NoLineDirectives, c.SmartScope, c.Lenient, c.Header, c.StampBuildTag)
+}
@@ -0,0 +1,36 @@
+package gox
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/llyb120/gox/parser"
+)
+
+func TestManifestLenientChange(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"dao/user.gox.go": "//go:build ignore\n\npackage dao\n\nimport \"github.com/llyb120/gox\"\n\n" +
+			"func Find() gox.Query {\n\treturn gox.Sql(`select } from t`)\n}\n",
+	})
+
+	build := func(lenient bool) error {
+		c := newTestCompiler(dir)
+		c.Layout = LayoutAlongside
+		c.Manifest = true
+		c.Incremental = true
+		c.Lenient = lenient
+		return c.Compile()
+	}
+
+	if err := build(true); err != nil {
+		t.Fatalf("lenient build: %v", err)
+	}
+	var te *parser.TemplateError
+	if err := build(false); !errors.As(err, &te) {
+		t.Errorf("strict build after lenient build: error = %v, want a *parser.TemplateError", err)
+	}
+	if err := build(true); err != nil {
+		t.Errorf("lenient build after strict build: %v", err)
+	}
+}
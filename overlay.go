@@ -0,0 +1,125 @@
+package gox
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// overlay go build -overlay 使用的 JSON 文件，把 .gox.go 源文件替换为缓存目录中的生成文件
+// 格式为 {"Replace": {"/abs/dao/user.gox.go": "/cache/dao/user_gen.go"}}
+type overlay struct {
+	Replace map[string]string `json:"Replace"` // 源文件绝对路径 -> 生成文件绝对路径
+
+	mu    sync.Mutex
+	path  string // overlay 文件的绝对路径
+	dirty bool   // 是否有未保存的修改
+}
+
+// loadOverlay 读取已有的 overlay 文件，保留其中其他源文件的记录；文件不存在或损坏时返回空记录
+func loadOverlay(path string) (*overlay, error) {
+	o := &overlay{Replace: make(map[string]string), path: path}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			o.dirty = true
+			return o, nil
+		}
+		return nil, fmt.Errorf("读取 overlay 文件失败 %s: %v", path, err)
+	}
+
+	if err := json.Unmarshal(data, o); err != nil || o.Replace == nil {
+		return &overlay{Replace: make(map[string]string), path: path, dirty: true}, nil
+	}
+	return o, nil
+}
+
+// update 根据单个文件的编译结果更新替换记录，编译失败的源文件不再被替换
+func (o *overlay) update(r *fileResult) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	switch r.Status {
+	case fileGenerated, fileUnchanged, fileSkipped:
+		if o.Replace[r.Source] != r.Output {
+			o.Replace[r.Source] = r.Output
+			o.dirty = true
+		}
+	case fileFailed:
+		if _, ok := o.Replace[r.Source]; ok {
+			delete(o.Replace, r.Source)
+			o.dirty = true
+		}
+	}
+}
+
+// forget 删除源文件的替换记录
+func (o *overlay) forget(source string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if _, ok := o.Replace[source]; ok {
+		delete(o.Replace, source)
+		o.dirty = true
+	}
+}
+
+// save 删除源文件或生成文件已不存在的记录，并将 overlay 文件写回磁盘
+func (o *overlay) save() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	for src, out := range o.Replace {
+		if !fileExists(src) || !fileExists(out) {
+			delete(o.Replace, src)
+			o.dirty = true
+		}
+	}
+	if !o.dirty {
+		return nil
+	}
+
+	// encoding/json 按键排序输出，文件内容稳定
+	data, err := json.MarshalIndent(o, "", "  ")
+	if err != nil {
+		return err
+	}
+	if _, err := writeFileAtomic(o.path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("写入 overlay 文件失败 %s: %v", o.path, err)
+	}
+	o.dirty = false
+	return nil
+}
+
+// fileExists 检查文件是否存在
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// isDir 检查目录是否存在
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// defaultCacheDir 返回 overlay 模式的默认缓存目录：用户缓存目录下以源目录路径哈希命名的子目录，
+// 不同项目的生成文件互不干扰
+func defaultCacheDir(srcRoot string) (string, error) {
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("无法确定缓存目录，请设置 CacheDir: %v", err)
+	}
+	sum := sha256.Sum256([]byte(srcRoot))
+	return filepath.Join(base, "gox", hex.EncodeToString(sum[:8])), nil
+}
+
+// overlayMode 是否启用 overlay 模式
+func (c *Compiler) overlayMode() bool {
+	return c.Overlay != ""
+}
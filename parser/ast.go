@@ -6,11 +6,12 @@ import (
 )
 
 // SQLBlock 表示一个 SQL 代码块
+// 所有位置都属于 Parser.GetFileSet() 中的原始 .gox.go 源文件，没有位置信息时为 token.NoPos
 type SQLBlock struct {
-	Start   token.Pos
-	End     token.Pos
+	Start   token.Pos // gox.Sql( 调用（或 @@{ 表达式）的开始位置
+	End     token.Pos // 右括号（或右大括号）之后的位置
 	Content []SQLNode
-	VarName string // 生成的变量名
+	VarName string // 生成的变量名，@@{} 中的嵌套块在生成代码时才命名，为空
 }
 
 // SQLNode 接口表示 SQL 块中的节点
@@ -49,11 +50,13 @@ const (
 
 // SQLExpression 表示嵌入的 Go 表达式
 type SQLExpression struct {
-	StartPos token.Pos
-	EndPos   token.Pos
-	Type     SQLExpressionType
-	Content  string   // 原始表达式内容（可能是代码块）
-	Expr     ast.Expr // 解析后的表达式（简单表达式）或nil（复杂代码块）
+	StartPos   token.Pos
+	EndPos     token.Pos
+	ContentPos token.Pos // Content 第一个字符的位置，Content 中偏移为 i 的字符位于 ContentPos + i
+	Type       SQLExpressionType
+	Content    string    // 原始表达式内容（可能是代码块）
+	Expr       ast.Expr  // 解析后的表达式（简单表达式）或nil（复杂代码块）
+	Block      *SQLBlock // @@{} 查询表达式解析出的嵌套 SQL 块，其他类型为 nil
 }
 
 func (e *SQLExpression) Pos() token.Pos { return e.StartPos }
@@ -68,6 +71,8 @@ func (e *SQLExpression) String() string {
 // GoxFile 表示整个 .gox 文件的 AST
 type GoxFile struct {
 	*ast.File
+	Filename      string // 源文件名
+	Source        []byte // 源文件内容
 	SQLBlocks     []*SQLBlock
 	GeneratedCode string // 生成的Go代码
 }
@@ -0,0 +1,191 @@
+package parser
+
+import (
+	"fmt"
+	"go/build/constraint"
+	"slices"
+	"strings"
+)
+
+// sourceTags 用于把 .gox.go 源文件排除在正常构建之外的标签，不会复制到生成文件
+var sourceTags = map[string]bool{
+	"ignore": true,
+	"gox":    true,
+}
+
+// constraintLines 返回 package 声明之前的构建约束行（//go:build 和 // +build）的行号
+func constraintLines(lines []string) []int {
+	var result []int
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "package ") {
+			break
+		}
+		if constraint.IsGoBuild(trimmed) || constraint.IsPlusBuild(trimmed) {
+			result = append(result, i)
+		}
+	}
+	return result
+}
+
+// parseConstraint 解析源文件的构建约束，优先使用 //go:build，没有时合并所有 // +build 行；没有约束时返回 nil
+func parseConstraint(src string) (constraint.Expr, error) {
+	lines := strings.Split(src, "\n")
+
+	var plus constraint.Expr
+	for _, i := range constraintLines(lines) {
+		line := strings.TrimSpace(lines[i])
+		expr, err := constraint.Parse(line)
+		if err != nil {
+			return nil, fmt.Errorf("无效的构建约束 %q: %v", line, err)
+		}
+		if constraint.IsGoBuild(line) {
+			return expr, nil
+		}
+		if plus == nil {
+			plus = expr
+		} else {
+			plus = &constraint.AndExpr{X: plus, Y: expr}
+		}
+	}
+	return plus, nil
+}
+
+// removeConstraints 移除 package 声明之前的构建约束行
+func removeConstraints(code string) string {
+	lines := strings.Split(code, "\n")
+	remove := make(map[int]bool)
+	for _, i := range constraintLines(lines) {
+		remove[i] = true
+	}
+
+	result := make([]string, 0, len(lines))
+	for i, line := range lines {
+		if !remove[i] {
+			result = append(result, line)
+		}
+	}
+	return strings.Join(result, "\n")
+}
+
+// sourceTagNames 返回只用于排除源文件的标签：ignore、gox 以及编译时加到源文件上的 stampTag
+func sourceTagNames(stampTag string) []string {
+	names := []string{"ignore", "gox"}
+	if stampTag != "" && !sourceTags[stampTag] {
+		names = append(names, stampTag)
+	}
+	return names
+}
+
+// stripSourceTags 把约束中的 tags 标签视为成立并化简
+// 返回 nil 表示约束恒成立；ok 为 false 表示约束恒不成立
+func stripSourceTags(expr constraint.Expr, tags []string) (result constraint.Expr, ok bool) {
+	switch e := expr.(type) {
+	case *constraint.TagExpr:
+		if slices.Contains(tags, e.Tag) {
+			return nil, true
+		}
+		return e, true
+	case *constraint.NotExpr:
+		x, ok := stripSourceTags(e.X, tags)
+		switch {
+		case !ok:
+			return nil, true
+		case x == nil:
+			return nil, false
+		}
+		return &constraint.NotExpr{X: x}, true
+	case *constraint.AndExpr:
+		x, okX := stripSourceTags(e.X, tags)
+		y, okY := stripSourceTags(e.Y, tags)
+		switch {
+		case !okX || !okY:
+			return nil, false
+		case x == nil:
+			return y, true
+		case y == nil:
+			return x, true
+		}
+		return &constraint.AndExpr{X: x, Y: y}, true
+	case *constraint.OrExpr:
+		x, okX := stripSourceTags(e.X, tags)
+		y, okY := stripSourceTags(e.Y, tags)
+		switch {
+		case okX && x == nil, okY && y == nil:
+			return nil, true
+		case !okX:
+			return y, okY
+		case !okY:
+			return x, true
+		}
+		return &constraint.OrExpr{X: x, Y: y}, true
+	}
+	return expr, true
+}
+
+// outputConstraint 返回生成文件应当使用的 //go:build 行，源文件没有其他约束时返回空字符串
+// stampTag 为编译时加到源文件上的标签，与 ignore 和 gox 一样不复制到生成文件
+func outputConstraint(src, stampTag string) (string, error) {
+	expr, err := parseConstraint(src)
+	if err != nil || expr == nil {
+		return "", err
+	}
+
+	tags := sourceTagNames(stampTag)
+	stripped, ok := stripSourceTags(expr, tags)
+	if !ok {
+		return "", fmt.Errorf("构建约束 %q 去掉 %s 和 %s 标签后恒不成立",
+			expr.String(), strings.Join(tags[:len(tags)-1], "、"), tags[len(tags)-1])
+	}
+	if stripped == nil {
+		return "", nil
+	}
+	return "//go:build " + stripped.String(), nil
+}
+
+// requiresTag 检查约束是否要求 tag 成立（tag 是顶层 && 中的一项）
+func requiresTag(expr constraint.Expr, tag string) bool {
+	switch e := expr.(type) {
+	case *constraint.TagExpr:
+		return e.Tag == tag
+	case *constraint.AndExpr:
+		return requiresTag(e.X, tag) || requiresTag(e.Y, tag)
+	}
+	return false
+}
+
+// StampBuildTag 确保源文件只在 tag 标签下参与构建，避免源文件和生成文件同时编译进同一个包
+// 已有约束要求 tag 或 ignore 时不修改；已有其他约束时追加 && tag；返回是否修改了内容
+func StampBuildTag(src []byte, tag string) ([]byte, bool, error) {
+	content := string(src)
+	expr, err := parseConstraint(content)
+	if err != nil {
+		return nil, false, err
+	}
+	if expr != nil && (requiresTag(expr, tag) || requiresTag(expr, "ignore")) {
+		return src, false, nil
+	}
+
+	stamped := &constraint.TagExpr{Tag: tag}
+	if expr == nil {
+		// 没有约束时加在文件开头，后面空一行
+		return []byte("//go:build " + stamped.String() + "\n\n" + content), true, nil
+	}
+
+	// 替换第一行约束，其余约束行（// +build）合并到新的 //go:build 中
+	newExpr := &constraint.AndExpr{X: expr, Y: stamped}
+	lines := strings.Split(content, "\n")
+	indexes := constraintLines(lines)
+	result := make([]string, 0, len(lines))
+	for i, line := range lines {
+		switch {
+		case i == indexes[0]:
+			result = append(result, "//go:build "+newExpr.String())
+		case slices.Contains(indexes, i):
+			// 丢弃其余的约束行
+		default:
+			result = append(result, line)
+		}
+	}
+	return []byte(strings.Join(result, "\n")), true, nil
+}
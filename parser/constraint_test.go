@@ -0,0 +1,60 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestOutputConstraint(t *testing.T) {
+	tests := []struct {
+		src      string
+		stampTag string
+		want     string
+		err      string
+	}{
+		{src: "package p\n", want: ""},
+		{src: "//go:build ignore\n\npackage p\n", want: ""},
+		{src: "//go:build gox && linux\n\npackage p\n", want: "//go:build linux"},
+		{src: "//go:build mytag\n\npackage p\n", want: "//go:build mytag"},
+		{src: "//go:build mytag\n\npackage p\n", stampTag: "mytag", want: ""},
+		{src: "//go:build linux && mytag\n\npackage p\n", stampTag: "mytag", want: "//go:build linux"},
+		{src: "//go:build (linux || darwin) && gox\n\npackage p\n", stampTag: "mytag", want: "//go:build linux || darwin"},
+		{src: "// +build linux\n// +build gox\n\npackage p\n", want: "//go:build linux"},
+		{src: "//go:build ignore || linux\n\npackage p\n", want: ""},
+		{src: "//go:build !gox\n\npackage p\n", err: "去掉 ignore 和 gox 标签后恒不成立"},
+		{src: "//go:build !mytag\n\npackage p\n", stampTag: "mytag", err: "去掉 ignore、gox 和 mytag 标签后恒不成立"},
+		{src: "//go:build linux &&\n\npackage p\n", err: "无效的构建约束"},
+	}
+	for _, tt := range tests {
+		got, err := outputConstraint(tt.src, tt.stampTag)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("outputConstraint(%q, %q) error = %v, want %q", tt.src, tt.stampTag, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("outputConstraint(%q, %q) = %q, %v, want %q", tt.src, tt.stampTag, got, err, tt.want)
+		}
+	}
+}
+
+func TestStampBuildTag(t *testing.T) {
+	tests := []struct {
+		src     string
+		want    string
+		changed bool
+	}{
+		{"package p\n", "//go:build mytag\n\npackage p\n", true},
+		{"//go:build mytag\n\npackage p\n", "//go:build mytag\n\npackage p\n", false},
+		{"//go:build ignore\n\npackage p\n", "//go:build ignore\n\npackage p\n", false},
+		{"//go:build linux\n\npackage p\n", "//go:build linux && mytag\n\npackage p\n", true},
+		{"// +build linux\n// +build amd64\n\npackage p\n", "//go:build linux && amd64 && mytag\n\npackage p\n", true},
+	}
+	for _, tt := range tests {
+		got, changed, err := StampBuildTag([]byte(tt.src), "mytag")
+		if err != nil || string(got) != tt.want || changed != tt.changed {
+			t.Errorf("StampBuildTag(%q) = %q, %v, %v, want %q, %v", tt.src, got, changed, err, tt.want, tt.changed)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package parser
+
+import "strings"
+
+// escapable 可以在 SQL 文本中用反斜杠转义的字符：\@ \# \$ \{ \} 分别输出字面的 @ # $ { }
+// 例如 PostgreSQL 的 tags \@> #{tags}、邮箱 'a\@b.com'、JSON 字面量 '\{"k":1\}'
+// 转义的 #、$、@ 后面紧跟的 { 也按文本处理，因此 \#{id} 输出 #{id}；其他位置的反斜杠保持原样
+const escapable = "@#${}"
+
+// isEscape 检查 content[i] 是否是转义序列开头的反斜杠
+func isEscape(content string, i int) bool {
+	return content[i] == '\\' && i+1 < len(content) && strings.IndexByte(escapable, content[i+1]) != -1
+}
+
+// unescapeSQL 去掉 SQL 文本中转义序列的反斜杠
+func unescapeSQL(text string) string {
+	if !strings.Contains(text, `\`) {
+		return text
+	}
+	var sb strings.Builder
+	for i := 0; i < len(text); i++ {
+		if isEscape(text, i) {
+			i++
+		}
+		sb.WriteByte(text[i])
+	}
+	return sb.String()
+}
+
+// indexSyntax 从 from 开始在代码块中查找模板语法 marker，返回绝对位置，找不到时返回 -1
+// 跳过转义序列、注释以及字符串和字符字面量（其中包括已经生成的 AddText 文本）；
+// 双引号和单引号不跨行，未闭合时只跳到行尾，避免 @ 语句中 SQL 文本的引号吞掉后面的代码
+func (p *Parser) indexSyntax(code string, from int, marker string) int {
+	for i := from; i < len(code); i++ {
+		c := code[i]
+		switch {
+		case isEscape(code, i):
+			i++
+		case c == '"' || c == '\'':
+			for i++; i < len(code) && code[i] != c && code[i] != '\n'; i++ {
+				if code[i] == '\\' && i+1 < len(code) && code[i+1] != '\n' {
+					i++
+				}
+			}
+		case c == '`':
+			i = p.skipStringLiteral(code, i, c) - 1
+		case strings.HasPrefix(code[i:], "//"):
+			end := strings.IndexByte(code[i:], '\n')
+			if end == -1 {
+				return -1
+			}
+			i += end
+		case strings.HasPrefix(code[i:], "/*"):
+			end := strings.Index(code[i+2:], "*/")
+			if end == -1 {
+				return -1
+			}
+			i += end + 3
+		case strings.HasPrefix(code[i:], marker):
+			return i
+		}
+	}
+	return -1
+}
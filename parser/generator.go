@@ -1,8 +1,13 @@
 package parser
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
 	"go/format"
 	"go/token"
+	"path/filepath"
+	"regexp"
 	"strings"
 )
 
@@ -10,6 +15,9 @@ import (
 type Generator struct {
 	fset           *token.FileSet
 	importAnalyzer *ImportAnalyzer
+	sourceName     string // 写入生成文件头的源文件名
+	headerText     string // 追加到生成文件头的注释文本
+	stampTag       string // 编译时加到源文件构建约束中的标签
 }
 
 // NewGenerator 创建新的生成器
@@ -20,12 +28,32 @@ func NewGenerator() *Generator {
 	}
 }
 
+// SetSourceName 设置生成文件头中记录的源文件名，默认为源文件的文件名
+// 通常设置为源文件相对生成文件所在目录的路径，保证在不同机器上生成相同的内容
+func (g *Generator) SetSourceName(name string) {
+	g.sourceName = name
+}
+
+// SetHeader 设置追加到生成文件头的注释文本，每行自动加上 // 前缀
+func (g *Generator) SetHeader(text string) {
+	g.headerText = text
+}
+
+// SetStampTag 设置编译时加到源文件构建约束中的标签，该标签和 ignore、gox 一样不复制到生成文件
+func (g *Generator) SetStampTag(tag string) {
+	g.stampTag = tag
+}
+
 // GenerateFile 生成Go文件
 func (g *Generator) GenerateFile(goxFile *GoxFile) ([]byte, error) {
 	code := goxFile.GeneratedCode
 
-	// 移除编译忽略指令（这些只应该在.gox.go文件中）
-	code = g.removeBuildIgnore(code)
+	// 构建约束中的 ignore、gox 和 stamp 标签只用于排除源文件，其余约束复制到生成文件
+	constraintLine, err := outputConstraint(string(goxFile.Source), g.stampTag)
+	if err != nil {
+		return nil, err
+	}
+	code = removeConstraints(code)
 
 	// 使用 ImportAnalyzer 分析并添加必要的导入
 	code = g.addNecessaryImports(code)
@@ -35,8 +63,38 @@ func (g *Generator) GenerateFile(goxFile *GoxFile) ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
+	formatted = fixLineDirectives(formatted)
 
-	return formatted, nil
+	header := g.header(goxFile)
+	if constraintLine != "" {
+		header += constraintLine + "\n\n"
+	}
+	return append([]byte(header), formatted...), nil
+}
+
+// header 生成标准的 "Code generated ... DO NOT EDIT." 文件头，并记录源文件哈希
+func (g *Generator) header(goxFile *GoxFile) string {
+	name := g.sourceName
+	if name == "" {
+		name = filepath.Base(goxFile.Filename)
+	}
+	sum := sha256.Sum256(goxFile.Source)
+
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "// Code generated by gox from %s. DO NOT EDIT.\n", name)
+	fmt.Fprintf(&buf, "// Source hash: sha256:%s\n", hex.EncodeToString(sum[:]))
+	if text := strings.TrimRight(g.headerText, "\n"); text != "" {
+		buf.WriteString("//\n")
+		for _, line := range strings.Split(text, "\n") {
+			if strings.HasPrefix(line, "//") {
+				buf.WriteString(line + "\n")
+			} else {
+				buf.WriteString(strings.TrimRight("// "+line, " ") + "\n")
+			}
+		}
+	}
+	buf.WriteString("\n")
+	return buf.String()
 }
 
 // addNecessaryImports 使用 ImportAnalyzer 添加必要的导入
@@ -96,17 +154,18 @@ func (g *Generator) extractExistingImports(code string) map[string]string {
 	return imports
 }
 
+// importSpecPattern 匹配一行导入声明：可选的 import 关键字和左括号、可选的别名以及导入路径
+var importSpecPattern = regexp.MustCompile(`^(?:import\s*\(?\s*)?(?:([\p{L}_.][\p{L}\p{N}_]*)\s+)?"([^"]+)"`)
+
 // parseImportBlock 解析导入块
 func (g *Generator) parseImportBlock(importBlock string) map[string]string {
 	imports := make(map[string]string)
 	lines := strings.Split(importBlock, "\n")
 
 	for _, line := range lines {
-		trimmed := strings.TrimSpace(line)
-		if strings.HasPrefix(trimmed, `"`) && strings.HasSuffix(trimmed, `"`) {
-			// 提取导入路径
-			importPath := strings.Trim(trimmed, `"`)
-			imports[importPath] = ""
+		// 提取导入路径以及重命名导入的别名（包括点导入和 _）
+		if m := importSpecPattern.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+			imports[m[2]] = m[1]
 		}
 	}
 
@@ -256,21 +315,3 @@ func (g *Generator) addStringsImport(code string) string {
 
 	return strings.Join(result, "\n")
 }
-
-// removeBuildIgnore 移除编译忽略指令
-func (g *Generator) removeBuildIgnore(code string) string {
-	lines := strings.Split(code, "\n")
-	var result []string
-
-	for _, line := range lines {
-		trimmed := strings.TrimSpace(line)
-		// 跳过编译忽略指令
-		if strings.HasPrefix(trimmed, "//go:build ignore") ||
-			strings.HasPrefix(trimmed, "// +build ignore") {
-			continue
-		}
-		result = append(result, line)
-	}
-
-	return strings.Join(result, "\n")
-}
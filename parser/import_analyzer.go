@@ -207,9 +207,7 @@ func (ia *ImportAnalyzer) GenerateImportBlock(imports map[string]string) string
 
 	// 输出标准库
 	for _, path := range stdLibs {
-		buf.WriteString("\t\"")
-		buf.WriteString(path)
-		buf.WriteString("\"\n")
+		writeImportSpec(&buf, imports[path], path)
 	}
 
 	// 如果有第三方库，添加空行分隔
@@ -219,11 +217,21 @@ func (ia *ImportAnalyzer) GenerateImportBlock(imports map[string]string) string
 
 	// 输出第三方库
 	for _, path := range thirdParty {
-		buf.WriteString("\t\"")
-		buf.WriteString(path)
-		buf.WriteString("\"\n")
+		writeImportSpec(&buf, imports[path], path)
 	}
 
 	buf.WriteString(")\n\n")
 	return buf.String()
 }
+
+// writeImportSpec 输出一行导入声明，name 非空时保留重命名导入的别名
+func writeImportSpec(buf *strings.Builder, name, path string) {
+	buf.WriteString("\t")
+	if name != "" {
+		buf.WriteString(name)
+		buf.WriteString(" ")
+	}
+	buf.WriteString("\"")
+	buf.WriteString(path)
+	buf.WriteString("\"\n")
+}
@@ -0,0 +1,394 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"go/scanner"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// textEdit 对源文件的一次替换，Start == End 时为插入
+type textEdit struct {
+	Start int
+	End   int
+	Text  string
+}
+
+// applyEdits 按位置从后往前应用替换，避免位置偏移问题
+func applyEdits(content string, edits []textEdit) string {
+	sort.SliceStable(edits, func(i, j int) bool {
+		return edits[i].Start > edits[j].Start
+	})
+	for _, e := range edits {
+		content = content[:e.Start] + e.Text + content[e.End:]
+	}
+	return content
+}
+
+// SetLineDirectives 设置 //line 指令中使用的源文件名，为空时不输出 //line 指令
+// 文件名相对于生成文件所在目录，使编译错误和 panic 堆栈指向 .gox.go 源文件
+func (p *Parser) SetLineDirectives(filename string) {
+	p.lineFile = filename
+}
+
+// filePos 将源文件中的偏移转换为 token.Pos，超出范围时返回 token.NoPos
+func (p *Parser) filePos(offset int) token.Pos {
+	if p.file == nil || offset < 0 || offset > p.file.Size() {
+		return token.NoPos
+	}
+	return p.file.Pos(offset)
+}
+
+// lineDirective 返回把紧随其后的代码映射到源文件 offset 处的 /*line*/ 指令，未启用时返回空字符串
+func (p *Parser) lineDirective(offset int) string {
+	pos := p.filePos(offset)
+	if p.lineFile == "" || !pos.IsValid() {
+		return ""
+	}
+	position := p.file.Position(pos)
+
+	// gofmt 会在注释和同一行紧随的标识符、表达式之间插入一个空格，列号需要减一
+	column := position.Column
+	if offset < len(p.src) && strings.IndexByte(" \t\r\n,;.)]}", p.src[offset]) == -1 {
+		column--
+	}
+	if column < 1 {
+		return fmt.Sprintf("/*line %s:%d*/", p.lineFile, position.Line)
+	}
+	return fmt.Sprintf("/*line %s:%d:%d*/", p.lineFile, position.Line, column)
+}
+
+// standaloneLineDirective 返回位于行首的 //line 指令（以换行结束），把下一行代码映射到源文件 offset 处，未启用时返回空字符串
+// 列号是源文件中的列，格式化后由 fixLineDirectives 减去下一行的缩进
+func (p *Parser) standaloneLineDirective(offset int) string {
+	pos := p.filePos(offset)
+	if p.lineFile == "" || !pos.IsValid() {
+		return ""
+	}
+	position := p.file.Position(pos)
+	return fmt.Sprintf("//line %s:%d:%d\n", p.lineFile, position.Line, position.Column)
+}
+
+// exprOffset 返回表达式节点中用户代码在源文件中的开始位置，即 Content 去掉前导空白之后的位置；
+// 节点没有位置信息时返回 -1
+func (p *Parser) exprOffset(n *SQLExpression) int {
+	if p.file == nil || !n.ContentPos.IsValid() {
+		return -1
+	}
+
+	i := p.file.Offset(n.ContentPos)
+	for i < len(p.src) && strings.IndexByte(" \t\r\n", p.src[i]) != -1 {
+		i++
+	}
+	return i
+}
+
+// exprLineDirective 返回指向表达式节点中用户代码的 /*line*/ 指令
+func (p *Parser) exprLineDirective(n *SQLExpression) string {
+	return p.lineDirective(p.exprOffset(n))
+}
+
+// markCodeLines 在代码块每一行的行首以及每个 #{}、${} 表达式前插入指向源文件的 /*line*/ 指令，
+// code 为去掉首尾空白后的代码块内容；字符串和注释保持原样，避免指令混入 SQL 文本
+func (p *Parser) markCodeLines(code string, n *SQLExpression) string {
+	offset := p.exprOffset(n)
+	if p.lineFile == "" || offset < 0 {
+		return code
+	}
+	return p.markCode(code, offset, true)
+}
+
+// markSQLText 在 @{} 块或 @ 行的 SQL 文本中每个 #{}、${} 表达式前插入指向源文件的 /*line*/ 指令，
+// sql 为去掉首尾空白后的节点内容
+func (p *Parser) markSQLText(sql string, n *SQLExpression) string {
+	offset := p.exprOffset(n)
+	if p.lineFile == "" || offset < 0 {
+		return sql
+	}
+	return p.markSQL(sql, offset)
+}
+
+// markCode 为 Go 代码插入 /*line*/ 指令，offset 为 code 在源文件中的位置；
+// lines 为 true 时还在每一行的行首插入指令，嵌套在 SQL 文本中的代码块只标记表达式
+func (p *Parser) markCode(code string, offset int, lines bool) string {
+	var sb strings.Builder
+	lineStart := lines
+	indent := ""          // 当前行尚未写出的行首空白
+	afterComment := false // 上一个非空行只有注释
+	for i := 0; i < len(code); {
+		c := code[i]
+
+		if lineStart && (c == ' ' || c == '\t' || c == '\r') {
+			indent += string(c)
+			i++
+			continue
+		}
+		if lineStart && c != '\n' {
+			lineStart = false
+			isComment := strings.HasPrefix(code[i:], "//") || strings.HasPrefix(code[i:], "/*")
+			switch {
+			case c == '}' || isComment:
+				// gofmt 会把右大括号前的注释移到单独一行，注释前的指令也没有意义，这些行不插入指令
+				sb.WriteString(indent)
+			case afterComment:
+				// 紧跟在注释行之后的 /*line*/ 会被 gofmt 移到单独一行，改用位于行首的 //line 指令
+				sb.WriteString(p.standaloneLineDirective(offset + i))
+				sb.WriteString(indent)
+			default:
+				sb.WriteString(indent)
+				sb.WriteString(p.lineDirective(offset + i))
+			}
+			indent = ""
+			afterComment = isComment
+
+			// 智能作用域模式下 @ 语句可能跨行，其中的 SQL 文本不能插入行首指令，只标记表达式
+			if c == '@' && p.smartScopeMode {
+				lineEnd := i + 1
+				for lineEnd < len(code) && code[lineEnd] != '\n' && code[lineEnd] != '\r' {
+					lineEnd++
+				}
+				smartResult := p.trySmartScopeProcessing(i, code[i+1:lineEnd], code[i+1:], code)
+				if smartResult.ShouldHandle {
+					sb.WriteString(p.markCode(code[i:smartResult.LineEndPos], offset+i, false))
+					i = smartResult.LineEndPos
+					continue
+				}
+			}
+		}
+
+		switch {
+		case isEscape(code, i):
+			sb.WriteString(code[i : i+2])
+			i += 2
+		case c == '\n':
+			lineStart = lines
+			indent = ""
+			sb.WriteByte(c)
+			i++
+		case c == '"' || c == '`' || c == '\'':
+			end := p.skipStringLiteral(code, i, c)
+			sb.WriteString(code[i:end])
+			i = end
+		case strings.HasPrefix(code[i:], "//"):
+			end := strings.IndexByte(code[i:], '\n')
+			if end == -1 {
+				end = len(code) - i
+			}
+			sb.WriteString(code[i : i+end])
+			i += end
+		case strings.HasPrefix(code[i:], "/*"):
+			end := strings.Index(code[i+2:], "*/")
+			if end == -1 {
+				end = len(code) - i
+			} else {
+				end += 4
+			}
+			sb.WriteString(code[i : i+end])
+			i += end
+		default:
+			if end := p.markEmbedded(&sb, code, i, offset); end != -1 {
+				i = end
+				continue
+			}
+			sb.WriteByte(c)
+			i++
+		}
+	}
+	return sb.String()
+}
+
+// markSQL 为 SQL 文本中的 #{}、${} 表达式插入 /*line*/ 指令，offset 为 sql 在源文件中的位置；
+// 与 processSQLPartForParams 一致，SQL 文本中的引号不影响表达式的识别，不带前缀的 {} 按 Go 代码处理
+func (p *Parser) markSQL(sql string, offset int) string {
+	var sb strings.Builder
+	for i := 0; i < len(sql); {
+		c := sql[i]
+		switch {
+		case isEscape(sql, i):
+			sb.WriteString(sql[i : i+2])
+			i += 2
+			continue
+		case c == '{':
+			if content, end := p.findMatchingBrace(sql, i+1); end != -1 {
+				sb.WriteByte('{')
+				sb.WriteString(p.markCode(content, offset+i+1, false))
+				sb.WriteByte('}')
+				i = end + 1
+				continue
+			}
+		case c == '@' && p.smartScopeMode && !strings.HasPrefix(sql[i:], "@{") && !strings.HasPrefix(sql[i:], "@@{"):
+			// 智能作用域处理的 @ 语句整体作为 SQL 文本输出，不能插入指令
+			lineEnd := i + 1
+			for lineEnd < len(sql) && sql[lineEnd] != '\n' && sql[lineEnd] != '\r' {
+				lineEnd++
+			}
+			if smartResult := p.trySmartScopeProcessing(i, sql[i+1:lineEnd], sql[i+1:], sql); smartResult.ShouldHandle {
+				sb.WriteString(sql[i:smartResult.LineEndPos])
+				i = smartResult.LineEndPos
+				continue
+			}
+		default:
+			if end := p.markEmbedded(&sb, sql, i, offset); end != -1 {
+				i = end
+				continue
+			}
+		}
+		sb.WriteByte(c)
+		i++
+	}
+	return sb.String()
+}
+
+// markEmbedded 处理从 s[i] 开始的 #{}、${}、@{}、@@{} 表达式并写入 sb，返回表达式之后的位置；
+// 不是完整的表达式时返回 -1。#{}、${} 在表达式前插入指令，@{} 递归标记其中的 SQL 文本，
+// @@{} 会被重新解析为独立查询，保持原样
+func (p *Parser) markEmbedded(sb *strings.Builder, s string, i, offset int) int {
+	prefix := ""
+	for _, pre := range []string{"@@{", "@{", "#{", "${"} {
+		if strings.HasPrefix(s[i:], pre) {
+			prefix = pre
+			break
+		}
+	}
+	if prefix == "" {
+		return -1
+	}
+	start := i + len(prefix)
+	content, end := p.findMatchingBrace(s, start)
+	if end == -1 {
+		return -1
+	}
+
+	sb.WriteString(prefix)
+	switch prefix {
+	case "@@{":
+		sb.WriteString(content)
+	case "@{":
+		sb.WriteString(p.markSQL(content, offset+start))
+	default:
+		expr := start
+		for expr < end && strings.IndexByte(" \t\r\n", s[expr]) != -1 {
+			expr++
+		}
+		sb.WriteString(s[start:expr])
+		sb.WriteString(p.lineDirective(offset + expr))
+		sb.WriteString(s[expr:end])
+	}
+	sb.WriteByte('}')
+	return end + 1
+}
+
+// declLineDirectives 在每个顶层声明前插入 //line 指令，
+// 使 SQL 块展开后的行号偏移不影响后续 Go 代码的错误位置
+// 有文档注释的声明把指令放在文档注释之前并隔一个空行，避免 gofmt 把指令并入文档注释；
+// import 块会被生成器重建，不插入指令
+func (p *Parser) declLineDirectives(src []byte, blocks []SQLBlockInfo) []textEdit {
+	if p.lineFile == "" {
+		return nil
+	}
+
+	// 使用独立的文件集，避免扫描结果混入解析器的文件集
+	fset := token.NewFileSet()
+	file := fset.AddFile("", -1, len(src))
+
+	var s scanner.Scanner
+	s.Init(file, src, func(token.Position, string) {}, scanner.ScanComments)
+
+	var edits []textEdit
+	var doc token.Position // 当前连续的行首 // 注释中第一条的位置
+	lastCommentLine := 0
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		position := file.Position(pos)
+
+		switch tok {
+		case token.COMMENT:
+			if position.Column != 1 || !strings.HasPrefix(lit, "//") {
+				doc = token.Position{}
+			} else if !doc.IsValid() || position.Line != lastCommentLine+1 {
+				doc = position
+			}
+			lastCommentLine = position.Line
+			continue
+		case token.CONST, token.TYPE, token.VAR, token.FUNC:
+		default:
+			doc = token.Position{}
+			continue
+		}
+
+		// 只处理行首的声明关键字，并跳过 SQL 块内部
+		if position.Column == 1 && !inSQLBlock(position.Offset, blocks) {
+			if doc.IsValid() && lastCommentLine == position.Line-1 {
+				edits = append(edits, textEdit{
+					Start: doc.Offset,
+					End:   doc.Offset,
+					Text:  fmt.Sprintf("//line %s:%d:1\n\n", p.lineFile, doc.Line-1),
+				})
+			} else {
+				edits = append(edits, textEdit{
+					Start: position.Offset,
+					End:   position.Offset,
+					Text:  fmt.Sprintf("//line %s:%d:1\n", p.lineFile, position.Line),
+				})
+			}
+		}
+		doc = token.Position{}
+	}
+	return edits
+}
+
+// fixLineDirectives 修正格式化后位于行首、带列号的 //line 指令：
+// 指令中的列号对应下一行的第一个字符，需要减去 gofmt 为下一行生成的缩进，不足时去掉列号
+func fixLineDirectives(code []byte) []byte {
+	lines := bytes.Split(code, []byte("\n"))
+	for i := 0; i+1 < len(lines); i++ {
+		rest, ok := bytes.CutPrefix(lines[i], []byte("//line "))
+		if !ok {
+			continue
+		}
+		indent := len(lines[i+1]) - len(bytes.TrimLeft(lines[i+1], "\t"))
+		if indent == 0 {
+			continue
+		}
+
+		// 文件名中可能包含冒号，从右往左解析行号和列号
+		colSep := bytes.LastIndexByte(rest, ':')
+		if colSep == -1 {
+			continue
+		}
+		lineSep := bytes.LastIndexByte(rest[:colSep], ':')
+		if lineSep == -1 {
+			continue
+		}
+		if _, err := strconv.Atoi(string(rest[lineSep+1 : colSep])); err != nil {
+			continue
+		}
+		column, err := strconv.Atoi(string(rest[colSep+1:]))
+		if err != nil {
+			continue
+		}
+
+		if column -= indent; column < 1 {
+			lines[i] = fmt.Appendf(nil, "//line %s", rest[:colSep])
+		} else {
+			lines[i] = fmt.Appendf(nil, "//line %s:%d", rest[:colSep], column)
+		}
+	}
+	return bytes.Join(lines, []byte("\n"))
+}
+
+// inSQLBlock 判断偏移是否位于某个 SQL 块内部
+func inSQLBlock(offset int, blocks []SQLBlockInfo) bool {
+	for _, b := range blocks {
+		if offset >= b.Start && offset < b.End {
+			return true
+		}
+	}
+	return false
+}
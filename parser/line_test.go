@@ -0,0 +1,106 @@
+package parser
+
+import (
+	"fmt"
+	"go/ast"
+	goparser "go/parser"
+	"go/token"
+	"strings"
+	"testing"
+)
+
+func TestFixLineDirectives(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"//line a.gox.go:19:4\n\t\tif x {\n", "//line a.gox.go:19:2\n\t\tif x {\n"},
+		{"//line a.gox.go:19:2\n\t\t\tif x {\n", "//line a.gox.go:19\n\t\t\tif x {\n"},
+		{"//line C:/src/a.gox.go:7:5\n\tx := 1\n", "//line C:/src/a.gox.go:7:4\n\tx := 1\n"},
+		{"//line a.gox.go:29:1\nfunc F() {}\n", "//line a.gox.go:29:1\nfunc F() {}\n"},
+		{"//line a.gox.go:10:1\n\n// F 文档\n", "//line a.gox.go:10:1\n\n// F 文档\n"},
+		{"//line a.gox.go:19\n\tx := 1\n", "//line a.gox.go:19\n\tx := 1\n"},
+		{"\t/*line a.gox.go:23:3*/ s := x\n", "\t/*line a.gox.go:23:3*/ s := x\n"},
+	}
+	for _, tt := range tests {
+		if got := string(fixLineDirectives([]byte(tt.in))); got != tt.want {
+			t.Errorf("fixLineDirectives(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestDeclLineDirectives(t *testing.T) {
+	src := "package p\n\nimport \"fmt\"\n\n// F 文档\n// 第二行\nfunc F() {}\n\n// 不是文档\n\nvar x = fmt.Sprint()\n\ntype T int // 行尾注释\n"
+	p := NewParser()
+	p.SetLineDirectives("a.gox.go")
+	got := applyEdits(src, p.declLineDirectives([]byte(src), nil))
+
+	want := "package p\n\nimport \"fmt\"\n\n//line a.gox.go:4:1\n\n// F 文档\n// 第二行\nfunc F() {}\n\n// 不是文档\n\n" +
+		"//line a.gox.go:11:1\nvar x = fmt.Sprint()\n\n//line a.gox.go:13:1\ntype T int // 行尾注释\n"
+	if got != want {
+		t.Errorf("declLineDirectives =\n%s\nwant\n%s", got, want)
+	}
+}
+
+// paramLineSource 在顶层、@ 行、@{} 块和代码块中引用未定义的标识符
+const paramLineSource = "package dao\n" +
+	"\n" +
+	"import \"github.com/llyb120/gox\"\n" +
+	"\n" +
+	"func Find(id int) gox.Query {\n" +
+	"\treturn gox.Sql(`\n" +
+	"\t\tselect * from t where a = #{missA}\n" +
+	"\t\t{\n" +
+	"\t\t\tif id > 0 {\n" +
+	"\t\t\t\t@and b = #{missB} and x = ${ missX }\n" +
+	"\t\t\t}\n" +
+	"\t\t\t#{missC}\n" +
+	"\t\t\t@{ and c = #{missD} }\n" +
+	"\t\t}\n" +
+	"\t\t@and e = #{missE}\n" +
+	"\t\t@{ and f = #{missF} or g = #{ missG + 1 } }\n" +
+	"\t`)\n" +
+	"}\n"
+
+func TestParamLineDirectives(t *testing.T) {
+	// 源文件中每个标识符第一次出现的位置
+	want := map[string]string{}
+	for i, line := range strings.Split(paramLineSource, "\n") {
+		for _, name := range []string{"missA", "missB", "missC", "missD", "missE", "missF", "missG", "missX"} {
+			if col := strings.Index(line, name); col != -1 {
+				want[name] = fmt.Sprintf("a.gox.go:%d:%d", i+1, col+1)
+			}
+		}
+	}
+
+	for _, smart := range []bool{false, true} {
+		p := NewParser()
+		p.SetSmartScope(smart)
+		p.SetLineDirectives("a.gox.go")
+		goxFile, err := p.ParseFile("a.gox.go", []byte(paramLineSource))
+		if err != nil {
+			t.Fatal(err)
+		}
+		code, err := NewGenerator().GenerateFile(goxFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		fset := token.NewFileSet()
+		f, err := goparser.ParseFile(fset, "a_gen.go", code, goparser.ParseComments)
+		if err != nil {
+			t.Fatalf("%v\n%s", err, code)
+		}
+		got := map[string]string{}
+		ast.Inspect(f, func(n ast.Node) bool {
+			if id, ok := n.(*ast.Ident); ok && strings.HasPrefix(id.Name, "miss") {
+				got[id.Name] = fset.Position(id.Pos()).String()
+			}
+			return true
+		})
+		for name, pos := range want {
+			if got[name] != pos {
+				t.Errorf("smart=%v: %s at %s, want %s\n%s", smart, name, got[name], pos, code)
+			}
+		}
+	}
+}
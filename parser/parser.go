@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"errors"
 	"fmt"
 	"go/ast"
 	"go/format"
@@ -9,21 +10,32 @@ import (
 	"go/token"
 	"strconv"
 	"strings"
+	"unicode"
 )
 
 // Parser GoX 解析器
 type Parser struct {
 	fset           *token.FileSet
 	debugMode      bool
-	smartScopeMode bool // 智能作用域模式开关
+	smartScopeMode bool        // 智能作用域模式开关
+	smartScope     bool        // 默认启用智能作用域模式，不需要 gox:smart_scope 注释
+	lineFile       string      // //line 指令中使用的源文件名，为空时不输出
+	file           *token.File // 当前处理的源文件，用于记录 SQL 节点的位置
+	src            string      // 当前处理的源文件内容
+	goxName        string      // 当前文件中 gox 包的本地名称，点导入时为 "."
+	strict         bool        // 严格检查模板语法
 }
 
+// goxImportPath gox 包的导入路径
+const goxImportPath = "github.com/llyb120/gox"
+
 // NewParser 创建新的解析器
 func NewParser() *Parser {
 	return &Parser{
 		fset:           token.NewFileSet(),
 		debugMode:      false,
 		smartScopeMode: false,
+		strict:         true,
 	}
 }
 
@@ -32,6 +44,41 @@ func (p *Parser) SetDebugMode(debug bool) {
 	p.debugMode = debug
 }
 
+// SetSmartScope 设置是否默认启用智能作用域模式
+// 关闭时仍然可以通过文件中的 gox:smart_scope 注释为单个文件启用
+func (p *Parser) SetSmartScope(enabled bool) {
+	p.smartScope = enabled
+}
+
+// goxImportName 从文件的导入声明中解析 gox 包的本地名称：重命名导入时为别名，点导入时为 "."，
+// 其他情况（包括没有导入 gox 包或导入声明无法解析）为 gox
+func goxImportName(filename string, src []byte) string {
+	file, _ := parser.ParseFile(token.NewFileSet(), filename, src, parser.ImportsOnly)
+	if file == nil {
+		return "gox"
+	}
+	for _, imp := range file.Imports {
+		if path, err := strconv.Unquote(imp.Path.Value); err != nil || path != goxImportPath {
+			continue
+		}
+		if imp.Name != nil && imp.Name.Name != "_" {
+			return imp.Name.Name
+		}
+	}
+	return "gox"
+}
+
+// goxRef 返回生成代码中引用 gox 包成员 name 的写法，与文件导入 gox 包的方式一致
+func (p *Parser) goxRef(name string) string {
+	switch p.goxName {
+	case "":
+		return "gox." + name
+	case ".":
+		return name
+	}
+	return p.goxName + "." + name
+}
+
 // formatGoError 格式化Go解析错误，显示具体的错误位置和上下文
 func (p *Parser) formatGoError(err error, filename string, src []byte) error {
 	if err == nil {
@@ -98,7 +145,7 @@ func (p *Parser) formatGoError(err error, filename string, src []byte) error {
 			}
 		}
 
-		return fmt.Errorf(errorDetails.String())
+		return errors.New(errorDetails.String())
 	}
 
 	// 如果不是scanner.ErrorList，回退到原始错误格式
@@ -108,7 +155,7 @@ func (p *Parser) formatGoError(err error, filename string, src []byte) error {
 // ParseFile 解析 .gox 文件
 func (p *Parser) ParseFile(filename string, src []byte) (*GoxFile, error) {
 	// 先预处理文件，替换 SQL 块为合法的 Go 代码
-	processed, sqlBlocks, err := p.preprocessFile(src)
+	processed, sqlBlocks, err := p.preprocessFile(filename, src)
 	if err != nil {
 		return nil, fmt.Errorf("预处理失败: %w", err)
 	}
@@ -137,24 +184,33 @@ func (p *Parser) ParseFile(filename string, src []byte) (*GoxFile, error) {
 
 	return &GoxFile{
 		File:          file,
+		Filename:      filename,
+		Source:        src,
 		SQLBlocks:     sqlBlocks,
 		GeneratedCode: string(processed),
 	}, nil
 }
 
 // preprocessFile 预处理文件，提取 SQL 块并替换为 Go 代码
-func (p *Parser) preprocessFile(src []byte) ([]byte, []*SQLBlock, error) {
+func (p *Parser) preprocessFile(filename string, src []byte) ([]byte, []*SQLBlock, error) {
 	content := string(src)
 	var sqlBlocks []*SQLBlock
 	sqlCounter := 0
 
+	// 记录原始文件，SQL 节点的位置和 //line 指令都指向原始文件
+	p.src = content
+	p.file = p.fset.AddFile(filename, -1, len(src))
+	p.file.SetLinesForContent(src)
+	p.goxName = goxImportName(filename, src)
+
 	// 检测文件头的 gox:smart_scope 注释
-	p.smartScopeMode = strings.Contains(content, "gox:smart_scope")
+	p.smartScopeMode = p.smartScope || strings.Contains(content, "gox:smart_scope")
 
 	// 使用智能方法查找所有 SQL 块（支持嵌套）
 	sqlBlockInfo := p.findSQLBlocks(content)
+	edits := p.declLineDirectives(src, sqlBlockInfo)
 
-	// 从后往前替换，避免位置偏移问题
+	// 从后往前生成，保持变量编号顺序
 	for i := len(sqlBlockInfo) - 1; i >= 0; i-- {
 		info := sqlBlockInfo[i]
 
@@ -162,8 +218,18 @@ func (p *Parser) preprocessFile(src []byte) ([]byte, []*SQLBlock, error) {
 		varName := fmt.Sprintf("__gox_sql_%d", sqlCounter)
 		sqlCounter++
 
+		// 严格模式下先检查模板语法，错误位置指向源文件中的行列
+		if p.strict {
+			if err := p.checkTemplate(sqlContent, info.ContentStart); err != nil {
+				return nil, nil, err
+			}
+		}
+
 		// 解析 SQL 块内容
-		sqlBlock, err := p.parseSQLBlock(sqlContent, varName)
+		sqlBlock, err := p.parseSQLBlockAt(sqlContent, varName, info.ContentStart)
+		if err == nil {
+			sqlBlock.Start, sqlBlock.End = p.filePos(info.Start), p.filePos(info.End)
+		}
 		if err != nil {
 			// 计算在原始文件中的行号
 			beforeContent := content[:info.Start]
@@ -180,12 +246,12 @@ func (p *Parser) preprocessFile(src []byte) ([]byte, []*SQLBlock, error) {
 		// 添加到块列表的开头（因为我们是倒序处理的）
 		sqlBlocks = append([]*SQLBlock{sqlBlock}, sqlBlocks...)
 
-		// 替换为 Go 代码
-		replacement := p.generateGoCodeForSQL(sqlBlock)
-		content = content[:info.Start] + replacement + content[info.End:]
+		// 替换为 Go 代码，之后的代码通过 /*line*/ 指令回到原始位置
+		replacement := p.generateGoCodeForSQL(sqlBlock) + p.lineDirective(info.End)
+		edits = append(edits, textEdit{Start: info.Start, End: info.End, Text: replacement})
 	}
 
-	return []byte(content), sqlBlocks, nil
+	return []byte(applyEdits(content, edits)), sqlBlocks, nil
 }
 
 // ExpressionMatch 表示找到的表达式匹配
@@ -198,10 +264,11 @@ type ExpressionMatch struct {
 
 // SQLToken 表示SQL中的一个token
 type SQLToken struct {
-	Type    SQLTokenType
-	Content string
-	Start   int
-	End     int
+	Type         SQLTokenType
+	Content      string
+	Start        int
+	End          int
+	ContentStart int // Content 第一个字符在模板中的位置，Content 是模板中连续的一段
 }
 
 // SQLTokenType token类型
@@ -219,9 +286,15 @@ const (
 
 // parseSQLBlock 解析 SQL 块内容 - 使用栈式遍历方法
 func (p *Parser) parseSQLBlock(sqlContent, varName string) (*SQLBlock, error) {
+	return p.parseSQLBlockAt(sqlContent, varName, -1)
+}
+
+// parseSQLBlockAt 解析位于源文件 offset 处的 SQL 块内容，offset 为负数时不记录节点位置
+// 块本身的 Start/End 由调用方根据块在源文件中的写法设置
+func (p *Parser) parseSQLBlockAt(sqlContent, varName string, offset int) (*SQLBlock, error) {
 	// 使用栈式遍历解析SQL内容
 	tokens := p.tokenizeSQLContent(sqlContent)
-	nodes := p.tokensToNodes(tokens)
+	nodes := p.tokensToNodes(tokens, offset)
 
 	return &SQLBlock{
 		Content: nodes,
@@ -236,32 +309,41 @@ func (p *Parser) tokenizeSQLContent(content string) []SQLToken {
 	i := 0
 	textStart := 0
 
+	// addText 添加 textStart 到 end 之间的文本，只在确认后面是表达式时调用；
+	// 表达式不完整时继续按文本扫描，不能提前添加，否则同一段文本会出现在多个 token 中
+	addText := func(end int) {
+		if text := content[textStart:end]; strings.TrimSpace(text) != "" {
+			tokens = append(tokens, SQLToken{
+				Type:         SQLTokenText,
+				Content:      text,
+				Start:        textStart,
+				End:          end,
+				ContentStart: textStart,
+			})
+		}
+	}
+
 	for i < len(content) {
+		// 转义序列按普通文本处理，生成代码时再去掉反斜杠
+		if isEscape(content, i) {
+			i += 2
+			continue
+		}
+
 		// 检查各种表达式的开始
 		if i < len(content)-1 {
 			// 检查 #{expr}
 			if content[i] == '#' && content[i+1] == '{' {
-				// 添加前面的文本
-				if i > textStart {
-					text := content[textStart:i]
-					if strings.TrimSpace(text) != "" {
-						tokens = append(tokens, SQLToken{
-							Type:    SQLTokenText,
-							Content: text,
-							Start:   textStart,
-							End:     i,
-						})
-					}
-				}
-
 				// 解析 #{expr}
 				exprContent, end := p.findMatchingBrace(content, i+2)
 				if end != -1 {
+					addText(i)
 					tokens = append(tokens, SQLToken{
-						Type:    SQLTokenParam,
-						Content: exprContent,
-						Start:   i,
-						End:     end + 1,
+						Type:         SQLTokenParam,
+						Content:      exprContent,
+						Start:        i,
+						End:          end + 1,
+						ContentStart: i + 2,
 					})
 					i = end + 1
 					textStart = i
@@ -271,27 +353,16 @@ func (p *Parser) tokenizeSQLContent(content string) []SQLToken {
 
 			// 检查 ${expr}
 			if content[i] == '$' && content[i+1] == '{' {
-				// 添加前面的文本
-				if i > textStart {
-					text := content[textStart:i]
-					if strings.TrimSpace(text) != "" {
-						tokens = append(tokens, SQLToken{
-							Type:    SQLTokenText,
-							Content: text,
-							Start:   textStart,
-							End:     i,
-						})
-					}
-				}
-
 				// 解析 ${expr}
 				exprContent, end := p.findMatchingBrace(content, i+2)
 				if end != -1 {
+					addText(i)
 					tokens = append(tokens, SQLToken{
-						Type:    SQLTokenTextExpr,
-						Content: exprContent,
-						Start:   i,
-						End:     end + 1,
+						Type:         SQLTokenTextExpr,
+						Content:      exprContent,
+						Start:        i,
+						End:          end + 1,
+						ContentStart: i + 2,
 					})
 					i = end + 1
 					textStart = i
@@ -303,27 +374,16 @@ func (p *Parser) tokenizeSQLContent(content string) []SQLToken {
 			if content[i] == '@' {
 				if i+2 < len(content) && content[i+1] == '@' && content[i+2] == '{' {
 					// 处理 @@{...} 查询块语法
-					// 添加前面的文本
-					if i > textStart {
-						text := content[textStart:i]
-						if strings.TrimSpace(text) != "" {
-							tokens = append(tokens, SQLToken{
-								Type:    SQLTokenText,
-								Content: text,
-								Start:   textStart,
-								End:     i,
-							})
-						}
-					}
-
 					// 解析 @@{...} 块
 					blockContent, end := p.findMatchingBrace(content, i+3)
 					if end != -1 {
+						addText(i)
 						tokens = append(tokens, SQLToken{
-							Type:    SQLTokenDoubleAtBlock,
-							Content: blockContent,
-							Start:   i,
-							End:     end + 1,
+							Type:         SQLTokenDoubleAtBlock,
+							Content:      blockContent,
+							Start:        i,
+							End:          end + 1,
+							ContentStart: i + 3,
 						})
 						i = end + 1
 						textStart = i
@@ -331,28 +391,17 @@ func (p *Parser) tokenizeSQLContent(content string) []SQLToken {
 					}
 				} else if i+1 < len(content) && content[i+1] == '{' {
 					// 处理 @{...} 块语法
-					// 添加前面的文本
-					if i > textStart {
-						text := content[textStart:i]
-						if strings.TrimSpace(text) != "" {
-							tokens = append(tokens, SQLToken{
-								Type:    SQLTokenText,
-								Content: text,
-								Start:   textStart,
-								End:     i,
-							})
-						}
-					}
-
 					// 解析 @{...} 块
 					blockContent, end := p.findMatchingBrace(content, i+2)
 					if end != -1 {
+						addText(i)
 						// 存储 @{} 块内容，后续在代码生成时处理
 						tokens = append(tokens, SQLToken{
-							Type:    SQLTokenAtBlock,
-							Content: blockContent, // 存储原始内容用于生成代码
-							Start:   i,
-							End:     end + 1,
+							Type:         SQLTokenAtBlock,
+							Content:      blockContent, // 存储原始内容用于生成代码
+							Start:        i,
+							End:          end + 1,
+							ContentStart: i + 2,
 						})
 						i = end + 1
 						textStart = i
@@ -360,23 +409,16 @@ func (p *Parser) tokenizeSQLContent(content string) []SQLToken {
 					}
 				} else {
 					// 处理 @xxx 简写形式（到行尾为止）
-					// 添加前面的文本
-					if i > textStart {
-						text := content[textStart:i]
-						if strings.TrimSpace(text) != "" {
-							tokens = append(tokens, SQLToken{
-								Type:    SQLTokenText,
-								Content: text,
-								Start:   textStart,
-								End:     i,
-							})
-						}
-					}
+					addText(i)
 
 					// 查找行尾，同时记录第一个 { 的位置（表示进入Go代码块）
 					lineEnd := i + 1
 					originalBracePos := -1
 					for lineEnd < len(content) && content[lineEnd] != '\n' && content[lineEnd] != '\r' {
+						if isEscape(content, lineEnd) {
+							lineEnd += 2
+							continue
+						}
 						if content[lineEnd] == '{' && originalBracePos == -1 {
 							// 仅当不是 #{、${、@{ 开头时，才认为是纯代码块的起始
 							prev := lineEnd - 1
@@ -404,10 +446,11 @@ func (p *Parser) tokenizeSQLContent(content string) []SQLToken {
 					if smartResult.ShouldHandle {
 						// 将整个跨行内容作为一个智能作用域 AtBlock token
 						tokens = append(tokens, SQLToken{
-							Type:    SQLTokenAtBlock,
-							Content: strings.TrimSpace(smartResult.BlockContent),
-							Start:   i,
-							End:     smartResult.LineEndPos,
+							Type:         SQLTokenAtBlock,
+							Content:      strings.TrimSpace(smartResult.BlockContent),
+							Start:        i,
+							End:          smartResult.LineEndPos,
+							ContentStart: i + 1 + leadingSpace(smartResult.BlockContent),
 						})
 
 						// 添加换行符
@@ -424,13 +467,15 @@ func (p *Parser) tokenizeSQLContent(content string) []SQLToken {
 					}
 
 					// 默认处理：单行@xxx语句
+					contentStart := i + 1 + leadingSpace(atContent)
 					atContent = strings.TrimSpace(atContent)
 					if atContent != "" {
 						tokens = append(tokens, SQLToken{
-							Type:    SQLTokenAtLine,
-							Content: atContent,
-							Start:   i,
-							End:     atEnd,
+							Type:         SQLTokenAtLine,
+							Content:      atContent,
+							Start:        i,
+							End:          atEnd,
+							ContentStart: contentStart,
 						})
 					}
 
@@ -465,27 +510,16 @@ func (p *Parser) tokenizeSQLContent(content string) []SQLToken {
 		if content[i] == '{' {
 			// 确保不是其他语法的一部分
 			if i == 0 || (content[i-1] != '#' && content[i-1] != '$' && content[i-1] != '@') {
-				// 添加前面的文本
-				if i > textStart {
-					text := content[textStart:i]
-					if strings.TrimSpace(text) != "" {
-						tokens = append(tokens, SQLToken{
-							Type:    SQLTokenText,
-							Content: text,
-							Start:   textStart,
-							End:     i,
-						})
-					}
-				}
-
 				// 解析 {expr}
 				exprContent, end := p.findMatchingBrace(content, i+1)
 				if end != -1 {
+					addText(i)
 					tokens = append(tokens, SQLToken{
-						Type:    SQLTokenCodeBlock,
-						Content: exprContent,
-						Start:   i,
-						End:     end + 1,
+						Type:         SQLTokenCodeBlock,
+						Content:      exprContent,
+						Start:        i,
+						End:          end + 1,
+						ContentStart: i + 1,
 					})
 					i = end + 1
 					textStart = i
@@ -498,78 +532,106 @@ func (p *Parser) tokenizeSQLContent(content string) []SQLToken {
 	}
 
 	// 添加最后的文本
-	if textStart < len(content) {
-		text := content[textStart:]
-		if strings.TrimSpace(text) != "" {
-			tokens = append(tokens, SQLToken{
-				Type:    SQLTokenText,
-				Content: text,
-				Start:   textStart,
-				End:     len(content),
-			})
-		}
-	}
+	addText(len(content))
 
 	return tokens
 }
 
-// tokensToNodes 将tokens转换为SQL节点
-func (p *Parser) tokensToNodes(tokens []SQLToken) []SQLNode {
+// leadingSpace 返回 s 开头空白字符的字节数，与 strings.TrimSpace 去掉的开头部分一致
+func leadingSpace(s string) int {
+	return len(s) - len(strings.TrimLeftFunc(s, unicode.IsSpace))
+}
+
+// tokensToNodes 将tokens转换为SQL节点，base 为内容在源文件中的开始位置，为负数时不记录节点位置
+func (p *Parser) tokensToNodes(tokens []SQLToken, base int) []SQLNode {
 	var nodes []SQLNode
 
-	for _, token := range tokens {
-		switch token.Type {
+	for _, tok := range tokens {
+		var start, end, contentPos token.Pos
+		if base >= 0 {
+			start, end = p.filePos(base+tok.Start), p.filePos(base+tok.End)
+			contentPos = p.filePos(base + tok.ContentStart)
+		}
+
+		switch tok.Type {
 		case SQLTokenText:
 			nodes = append(nodes, &SQLText{
-				Text: token.Content,
+				StartPos: start,
+				EndPos:   end,
+				Text:     tok.Content,
 			})
 
 		case SQLTokenParam:
 			// #{expr} - 参数化查询
 			nodes = append(nodes, &SQLExpression{
-				Type:    SQLExprParam,
-				Content: token.Content,
-				Expr:    p.tryParseExpr(token.Content), // 尝试解析为简单表达式
+				StartPos:   start,
+				EndPos:     end,
+				ContentPos: contentPos,
+				Type:       SQLExprParam,
+				Content:    tok.Content,
+				Expr:       p.tryParseExpr(tok.Content), // 尝试解析为简单表达式
 			})
 
 		case SQLTokenTextExpr:
 			// ${expr} - 文本表达式
 			nodes = append(nodes, &SQLExpression{
-				Type:    SQLExprText,
-				Content: token.Content,
-				Expr:    p.tryParseExpr(token.Content), // 尝试解析为简单表达式
+				StartPos:   start,
+				EndPos:     end,
+				ContentPos: contentPos,
+				Type:       SQLExprText,
+				Content:    tok.Content,
+				Expr:       p.tryParseExpr(tok.Content), // 尝试解析为简单表达式
 			})
 
 		case SQLTokenAtBlock:
 			// @{...} - 文本块，递归处理内部内容
 			nodes = append(nodes, &SQLExpression{
-				Type:    SQLExprAtText,
-				Content: token.Content,
-				Expr:    nil, // @{} 块总是复杂内容
+				StartPos:   start,
+				EndPos:     end,
+				ContentPos: contentPos,
+				Type:       SQLExprAtText,
+				Content:    tok.Content,
+				Expr:       nil, // @{} 块总是复杂内容
 			})
 
 		case SQLTokenAtLine:
 			// @xxx - 简写形式，直接输出到行尾的内容
 			nodes = append(nodes, &SQLExpression{
-				Type:    SQLExprAtText,
-				Content: token.Content,
-				Expr:    nil, // @xxx 简写形式当作文本处理
+				StartPos:   start,
+				EndPos:     end,
+				ContentPos: contentPos,
+				Type:       SQLExprAtText,
+				Content:    tok.Content,
+				Expr:       nil, // @xxx 简写形式当作文本处理
 			})
 
 		case SQLTokenDoubleAtBlock:
-			// @@{...} - 查询块，返回gox.Query
+			// @@{...} - 查询块，返回gox.Query，内容递归解析为嵌套的 SQL 块
+			contentBase := -1
+			if base >= 0 {
+				contentBase = base + tok.ContentStart
+			}
+			block, _ := p.parseSQLBlockAt(tok.Content, "", contentBase)
+			block.Start, block.End = start, end
 			nodes = append(nodes, &SQLExpression{
-				Type:    SQLExprDoubleAtQuery,
-				Content: token.Content,
-				Expr:    nil, // @@{} 块总是复杂内容
+				StartPos:   start,
+				EndPos:     end,
+				ContentPos: contentPos,
+				Type:       SQLExprDoubleAtQuery,
+				Content:    tok.Content,
+				Expr:       nil, // @@{} 块总是复杂内容
+				Block:      block,
 			})
 
 		case SQLTokenCodeBlock:
 			// {...} - 纯Go代码块
 			nodes = append(nodes, &SQLExpression{
-				Type:    SQLExprCode,
-				Content: token.Content,
-				Expr:    p.tryParseExpr(token.Content), // 尝试解析为简单表达式
+				StartPos:   start,
+				EndPos:     end,
+				ContentPos: contentPos,
+				Type:       SQLExprCode,
+				Content:    tok.Content,
+				Expr:       p.tryParseExpr(tok.Content), // 尝试解析为简单表达式
 			})
 		}
 	}
@@ -599,7 +661,7 @@ func (p *Parser) processCodeBlockExpressions(codeContent string, builderName str
 
 	// 处理所有 @@{...} 表达式（独立查询，返回gox.Query）
 	for {
-		start := strings.Index(result, "@@{")
+		start := p.indexSyntax(result, 0, "@@{")
 		if start == -1 {
 			break
 		}
@@ -630,7 +692,7 @@ func (p *Parser) processCodeBlockExpressions(codeContent string, builderName str
 
 	// 查找所有 @{...} 表达式（SQL文本块）
 	for {
-		start := strings.Index(result, "@{")
+		start := p.indexSyntax(result, 0, "@{")
 		if start == -1 {
 			break
 		}
@@ -671,11 +733,10 @@ func (p *Parser) processCodeBlockExpressions(codeContent string, builderName str
 	// 新增: 处理单行 @xxx 简写文本输出
 	searchStart := 0
 	for {
-		idx := strings.Index(result[searchStart:], "@")
+		idx := p.indexSyntax(result, searchStart, "@")
 		if idx == -1 {
 			break
 		}
-		idx += searchStart // 调整为绝对位置
 
 		// 如果是 @@{ 或 @{，则跳过，由上面的逻辑处理
 		if idx+2 < len(result) && result[idx+1] == '@' && result[idx+2] == '{' {
@@ -693,6 +754,10 @@ func (p *Parser) processCodeBlockExpressions(codeContent string, builderName str
 		lineEnd := idx
 		originalBracePos := -1
 		for lineEnd < len(result) && result[lineEnd] != '\n' && result[lineEnd] != '\r' {
+			if isEscape(result, lineEnd) {
+				lineEnd += 2
+				continue
+			}
 			if result[lineEnd] == '{' && originalBracePos == -1 {
 				prev := lineEnd - 1
 				if prev >= idx+1 && result[prev] != '#' && result[prev] != '$' && result[prev] != '@' {
@@ -770,7 +835,7 @@ func (p *Parser) processCodeBlockExpressions(codeContent string, builderName str
 
 	// 处理独立的 #{...} 表达式（参数化查询）
 	for {
-		start := strings.Index(result, "#{")
+		start := p.indexSyntax(result, 0, "#{")
 		if start == -1 {
 			break
 		}
@@ -804,7 +869,7 @@ func (p *Parser) processCodeBlockExpressions(codeContent string, builderName str
 
 	// 处理独立的 ${...} 表达式（直接输出变量）
 	for {
-		start := strings.Index(result, "${")
+		start := p.indexSyntax(result, 0, "${")
 		if start == -1 {
 			break
 		}
@@ -843,7 +908,7 @@ func (p *Parser) processCodeBlockExpressions(codeContent string, builderName str
 func (p *Parser) generateGoCodeForSQL(block *SQLBlock) string {
 	var parts []string
 
-	parts = append(parts, fmt.Sprintf("%s := gox.NewQueryBuilder()", block.VarName+"_builder"))
+	parts = append(parts, fmt.Sprintf("%s := %s()", block.VarName+"_builder", p.goxRef("NewQueryBuilder")))
 
 	for _, node := range block.Content {
 		switch n := node.(type) {
@@ -865,55 +930,60 @@ func (p *Parser) generateGoCodeForSQL(block *SQLBlock) string {
 
 				// 添加非注释行
 				if line != "" || i < len(lines)-1 { // 保留空行，除非是最后一行
-					parts = append(parts, fmt.Sprintf("%s.AddText(%s)", block.VarName+"_builder", strconv.Quote(line)))
+					parts = append(parts, fmt.Sprintf("%s.AddText(%s)", block.VarName+"_builder", strconv.Quote(unescapeSQL(line))))
 					if i < len(lines)-1 { // 不是最后一行则添加换行符
 						parts = append(parts, fmt.Sprintf("%s.AddText(%s)", block.VarName+"_builder", strconv.Quote("\n")))
 					}
 				}
 			}
 		case *SQLExpression:
+			// 用户代码前的 /*line*/ 指令，未启用时为空字符串
+			line := p.exprLineDirective(n)
+
 			switch n.Type {
 			case SQLExprText:
 				// ${expr} 或 {expr} - 直接输出变量或表达式
 				if n.Expr != nil {
 					// 简单表达式
-					parts = append(parts, fmt.Sprintf("%s.AddText(%s)",
-						block.VarName+"_builder", p.exprToString(n.Expr)))
+					parts = append(parts, fmt.Sprintf("%s.AddText(%s%s)",
+						block.VarName+"_builder", line, p.exprToString(n.Expr)))
 				} else {
 					// 复杂代码块 - 处理其中的 @{}, #{}, ${} 表达式
-					codeContent := strings.TrimSpace(n.Content)
+					codeContent := p.markCodeLines(strings.TrimSpace(n.Content), n)
 					processedCode := p.processCodeBlockExpressions(codeContent, block.VarName+"_builder")
 					parts = append(parts, processedCode)
 				}
 			case SQLExprAtText:
 				// @{...} - SQL文本块，在智能作用域模式下需要智能处理
-				sqlContent := strings.TrimSpace(n.Content)
+				sqlContent := p.markSQLText(strings.TrimSpace(n.Content), n)
 
 				if p.smartScopeMode {
 					// 智能作用域模式：区分SQL文本和Go代码块
 					smartParts := p.processSmartScopeContent(sqlContent, block.VarName+"_builder")
-					parts = append(parts, smartParts...)
+					for _, part := range smartParts {
+						parts = append(parts, line+part)
+					}
 				} else {
 					// 传统模式：直接处理 @{} 块内容
 					processedSQL, paramCalls := p.processSQLPartForParams(sqlContent, block.VarName+"_builder")
 					if processedSQL != "" {
-						parts = append(parts, fmt.Sprintf("%s.AddText(%s)",
-							block.VarName+"_builder", strconv.Quote(processedSQL)))
+						parts = append(parts, fmt.Sprintf("%s%s.AddText(%s)",
+							line, block.VarName+"_builder", strconv.Quote(processedSQL)))
 					}
 					// 添加参数调用
 					for _, paramCall := range paramCalls {
-						parts = append(parts, paramCall)
+						parts = append(parts, line+paramCall)
 					}
 				}
 			case SQLExprParam:
 				// #{expr} - 参数化表达式
 				if n.Expr != nil {
 					// 简单表达式
-					parts = append(parts, fmt.Sprintf("%s.AddParam(%s)",
-						block.VarName+"_builder", p.exprToString(n.Expr)))
+					parts = append(parts, fmt.Sprintf("%s.AddParam(%s%s)",
+						block.VarName+"_builder", line, p.exprToString(n.Expr)))
 				} else {
 					// 复杂代码块 - 使用具名返回值包装
-					codeContent := strings.TrimSpace(n.Content)
+					codeContent := p.markCodeLines(strings.TrimSpace(n.Content), n)
 					parts = append(parts, fmt.Sprintf("if __result := func() interface{} {\n\t\t\t%s\n\t\t\treturn nil\n\t\t}(); __result != nil {\n\t\t\t%s.AddParam(__result)\n\t\t}",
 						codeContent, block.VarName+"_builder"))
 				}
@@ -924,7 +994,7 @@ func (p *Parser) generateGoCodeForSQL(block *SQLBlock) string {
 				continue
 			case SQLExprCode:
 				// {...} - 纯Go代码块，直接执行，不生成AddText或AddParam
-				codeContent := strings.TrimSpace(n.Content)
+				codeContent := p.markCodeLines(strings.TrimSpace(n.Content), n)
 				processedCode := p.processCodeBlockExpressions(codeContent, block.VarName+"_builder")
 				parts = append(parts, processedCode)
 			}
@@ -934,7 +1004,7 @@ func (p *Parser) generateGoCodeForSQL(block *SQLBlock) string {
 	parts = append(parts, fmt.Sprintf("%s := %s.Build()",
 		block.VarName, block.VarName+"_builder"))
 
-	return "func()(__result gox.Query) {\n\t\t" + strings.Join(parts, "\n\t\t") + "\n\t\treturn " + block.VarName + "\n\t}()"
+	return "func()(__result " + p.goxRef("Query") + ") {\n\t\t" + strings.Join(parts, "\n\t\t") + "\n\t\treturn " + block.VarName + "\n\t}()"
 }
 
 // exprToString 将表达式转换为字符串
@@ -991,7 +1061,7 @@ func (p *Parser) preprocessNestedSQL(content string) (string, error) {
 		sqlContent := block.Content
 		sqlBlock, err := p.parseSQLBlock(sqlContent, fmt.Sprintf("__nested_sql_%d", sqlCount))
 		if err != nil {
-			return "", fmt.Errorf("解析嵌套SQL块失败: %v", err)
+			return "", fmt.Errorf("解析嵌套SQL块失败: %w", err)
 		}
 
 		// 生成嵌套SQL块的代码
@@ -1008,9 +1078,10 @@ func (p *Parser) preprocessNestedSQL(content string) (string, error) {
 
 // SQLBlockInfo 表示找到的SQL块信息
 type SQLBlockInfo struct {
-	Start   int    // 块的开始位置（包含Query函数调用）
-	End     int    // 块的结束位置（包含右括号）
-	Content string // SQL内容（不包含Query函数调用和引号）
+	Start        int    // 块的开始位置（包含Query函数调用）
+	End          int    // 块的结束位置（包含右括号）
+	Content      string // SQL内容（不包含Query函数调用和引号）
+	ContentStart int    // SQL内容在文件中的开始位置
 }
 
 // findSQLBlocks 智能查找所有SQL块，支持嵌套 - 新语法 Query(`...`) 和 Query('...')
@@ -1018,19 +1089,40 @@ func (p *Parser) findSQLBlocks(content string) []SQLBlockInfo {
 	var blocks []SQLBlockInfo
 	i := 0
 
+	// gox 包可能被重命名导入或点导入
+	sqlCall := p.goxRef("Sql") + "("
+
 	for i < len(content) {
-		// 跳过普通字符串字面量（双引号）
-		if content[i] == '"' {
-			i = p.skipStringLiteral(content, i, '"')
+		// 跳过字符串、字符字面量和注释，其中出现的 gox.Sql( 只是文本，不是真正的调用
+		switch {
+		case content[i] == '"' || content[i] == '`' || content[i] == '\'':
+			i = p.skipStringLiteral(content, i, content[i])
+			continue
+		case strings.HasPrefix(content[i:], "//"):
+			end := strings.IndexByte(content[i:], '\n')
+			if end == -1 {
+				return blocks
+			}
+			i += end
+			continue
+		case strings.HasPrefix(content[i:], "/*"):
+			end := strings.Index(content[i+2:], "*/")
+			if end == -1 {
+				return blocks
+			}
+			i += end + 4
 			continue
 		}
 
-		// 查找 "gox.Sql(" 或 "runtime.Query(" 函数调用
+		// 查找 "gox.Sql("（gox 为文件中 gox 包的本地名称）或 "runtime.Query(" 函数调用，
+		// 前面紧跟标识符或 . 时只是名字的一部分（例如 mygox.Sql(）
 		var funcLen int
 		var isQueryCall bool
 
-		if i+8 <= len(content) && content[i:i+8] == "gox.Sql(" {
-			funcLen = 8
+		if i > 0 && (isIdentByte(content[i-1]) || content[i-1] == '.') {
+			// 不是调用的开始
+		} else if strings.HasPrefix(content[i:], sqlCall) {
+			funcLen = len(sqlCall)
 			isQueryCall = true
 		} else if i+15 <= len(content) && content[i:i+15] == "runtime.Query(" {
 			funcLen = 15
@@ -1053,6 +1145,7 @@ func (p *Parser) findSQLBlocks(content string) []SQLBlockInfo {
 
 			var (
 				sqlContent string // 提取出的 SQL 文本
+				sqlStart   int    // SQL 文本在文件中的开始位置
 				endPos     int    // 当前 Query(...) 调用整体的结束位置（包含右括号）
 			)
 
@@ -1080,6 +1173,7 @@ func (p *Parser) findSQLBlocks(content string) []SQLBlockInfo {
 				}
 
 				sqlContent = content[contentStart:sqlEnd]
+				sqlStart = contentStart
 				endPos = closeParenPos + 1
 
 				// 情况 2：/* ... */ 注释块包裹
@@ -1102,6 +1196,7 @@ func (p *Parser) findSQLBlocks(content string) []SQLBlockInfo {
 				}
 
 				sqlContent = content[commentStart:commentEnd]
+				sqlStart = commentStart
 
 				// 跳过 "*/"
 				afterComment := commentEnd + 2
@@ -1126,9 +1221,10 @@ func (p *Parser) findSQLBlocks(content string) []SQLBlockInfo {
 
 			// 记录 SQL 块信息
 			blocks = append(blocks, SQLBlockInfo{
-				Start:   i,
-				End:     endPos,
-				Content: sqlContent,
+				Start:        i,
+				End:          endPos,
+				Content:      sqlContent,
+				ContentStart: sqlStart,
 			})
 
 			i = endPos
@@ -1180,6 +1276,13 @@ func (p *Parser) processSQLPartForParams(sqlPart string, builderName string) (st
 
 	i := 0
 	for i < len(sqlPart) {
+		// 0. 转义序列输出字面字符
+		if isEscape(sqlPart, i) {
+			textBuf.WriteByte(sqlPart[i+1])
+			i += 2
+			continue
+		}
+
 		// 1. 处理 #{ ... } 参数占位
 		if i+1 < len(sqlPart) && sqlPart[i] == '#' && sqlPart[i+1] == '{' {
 			if content, end := p.findMatchingBrace(sqlPart, i+2); end != -1 {
@@ -1261,7 +1364,7 @@ func (p *Parser) processSQLPartForParams(sqlPart string, builderName string) (st
 					smartResult := p.trySmartScopeProcessing(i, lineContent, sqlPart[i+1:], sqlPart)
 					if smartResult.ShouldHandle {
 						// 在智能作用域模式下，直接将跨行内容作为文本处理，不进行递归解析
-						calls = append(calls, fmt.Sprintf("%s.AddText(%s)", builderName, strconv.Quote(strings.TrimSpace(smartResult.BlockContent))))
+						calls = append(calls, fmt.Sprintf("%s.AddText(%s)", builderName, strconv.Quote(unescapeSQL(strings.TrimSpace(smartResult.BlockContent)))))
 
 						// 添加换行符
 						calls = append(calls, fmt.Sprintf("%s.AddText(%s)", builderName, strconv.Quote("\n")))
@@ -1327,6 +1430,10 @@ func (p *Parser) findMatchingBrace(content string, start int) (string, int) {
 			braceCount++
 		case '}':
 			braceCount--
+		case '\\':
+			if isEscape(content, i) {
+				i++ // 跳过转义的 { 和 }
+			}
 		case '"', '\'', '`':
 			// 跳过字符串字面量
 			i = p.skipStringLiteral(content, i, content[i]) - 1 // -1 因为for循环会+1
@@ -1427,6 +1534,11 @@ func (p *Parser) skipStringLiteral(content string, pos int, quote byte) int {
 	return i
 }
 
+// isIdentByte 检查字节是否可以出现在标识符中，非 ASCII 字节视为标识符的一部分
+func isIdentByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c >= 0x80
+}
+
 // isFollowingIdentifier 检查指定位置的左括号前面是否紧跟着标识符（无空格）
 func (p *Parser) isFollowingIdentifier(content string, parenPos int) bool {
 	if parenPos == 0 {
@@ -1520,7 +1632,7 @@ func (p *Parser) processSmartScopeContent(content string, builderName string) []
 			break
 		}
 
-		// 检查特殊表达式：#{...}、${...}、@{...}、@@{...}
+		// 检查特殊表达式：#{...}、${...}、@{...}、@@{...}（转义序列留给下面的文本处理）
 		if i+1 < len(content) {
 			// 处理 #{...} 表达式
 			if content[i] == '#' && content[i+1] == '{' {
@@ -1589,8 +1701,12 @@ func (p *Parser) processSmartScopeContent(content string, builderName string) []
 		// 处理SQL文本部分 - 找到下一个特殊字符或内容结尾
 		textStart := i
 		for i < len(content) {
-			// 遇到特殊字符就停止
-			if content[i] == '{' || content[i] == '#' || content[i] == '$' || content[i] == '@' {
+			if isEscape(content, i) {
+				i += 2
+				continue
+			}
+			// 遇到特殊字符就停止；开头的特殊字符没有构成表达式（例如 @>），按文本处理
+			if i > textStart && (content[i] == '{' || content[i] == '#' || content[i] == '$' || content[i] == '@') {
 				// 但是要确保不是在字符串中间
 				break
 			}
@@ -1598,7 +1714,7 @@ func (p *Parser) processSmartScopeContent(content string, builderName string) []
 		}
 
 		if i > textStart {
-			sqlText := strings.TrimSpace(content[textStart:i])
+			sqlText := strings.TrimSpace(unescapeSQL(content[textStart:i]))
 			if sqlText != "" {
 				// 对于纯SQL文本，直接添加为AddText调用
 				parts = append(parts, fmt.Sprintf("%s.AddText(%s)", builderName, strconv.Quote(sqlText)))
@@ -0,0 +1,128 @@
+package parser
+
+import (
+	"go/token"
+	"strings"
+	"testing"
+)
+
+const positionSource = "package dao\n" +
+	"\n" +
+	"import \"github.com/llyb120/gox\"\n" +
+	"\n" +
+	"func Find(id int, col string, names []string) gox.Query {\n" +
+	"\treturn gox.Sql(`\n" +
+	"\t\tselect ${col} from t where id = #{id}\n" +
+	"\t\t@{ and name = #{names[0]} }\n" +
+	"\t\t{\n" +
+	"\t\t\tif id > 0 {\n" +
+	"\t\t\t\t@and id > #{id}\n" +
+	"\t\t\t}\n" +
+	"\t\t}\n" +
+	"\t\t@or   name in (#{names})\n" +
+	"\t\tunion @@{select * from u where id = #{id}}\n" +
+	"\t`)\n" +
+	"}\n"
+
+// walkNodes 依次访问节点，包括 @@{} 中嵌套块的节点
+func walkNodes(nodes []SQLNode, fn func(SQLNode)) {
+	for _, n := range nodes {
+		fn(n)
+		if e, ok := n.(*SQLExpression); ok && e.Block != nil {
+			walkNodes(e.Block.Content, fn)
+		}
+	}
+}
+
+func TestNodePositions(t *testing.T) {
+	p := NewParser()
+	goxFile, err := p.ParseFile("a.gox.go", []byte(positionSource))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(goxFile.SQLBlocks) != 1 {
+		t.Fatalf("got %d SQL blocks, want 1", len(goxFile.SQLBlocks))
+	}
+	fset := p.GetFileSet()
+	offset := func(pos token.Pos) int { return fset.Position(pos).Offset }
+
+	block := goxFile.SQLBlocks[0]
+	if got := positionSource[offset(block.Start):offset(block.End)]; !strings.HasPrefix(got, "gox.Sql(`") || !strings.HasSuffix(got, "`)") {
+		t.Errorf("block spans %q", got)
+	}
+
+	var exprs []*SQLExpression
+	walkNodes(block.Content, func(n SQLNode) {
+		if !n.Pos().IsValid() || !n.End().IsValid() {
+			t.Errorf("node %q has no position", n)
+			return
+		}
+		span := positionSource[offset(n.Pos()):offset(n.End())]
+		switch n := n.(type) {
+		case *SQLText:
+			if span != n.Text && n.Pos() != n.End() {
+				t.Errorf("text %q spans %q", n.Text, span)
+			}
+		case *SQLExpression:
+			exprs = append(exprs, n)
+			start := offset(n.ContentPos)
+			if got := positionSource[start : start+len(n.Content)]; got != n.Content {
+				t.Errorf("expression %q: content at ContentPos is %q", n.Content, got)
+			}
+			if !strings.Contains(span, n.Content) {
+				t.Errorf("expression %q spans %q", n.Content, span)
+			}
+		}
+	})
+
+	tests := []struct {
+		typ     SQLExpressionType
+		content string
+		pos     string // 表达式开始位置
+		conPos  string // 内容开始位置
+	}{
+		{SQLExprText, "col", "a.gox.go:7:10", "a.gox.go:7:12"},
+		{SQLExprParam, "id", "a.gox.go:7:35", "a.gox.go:7:37"},
+		{SQLExprAtText, " and name = #{names[0]} ", "a.gox.go:8:3", "a.gox.go:8:5"},
+		{SQLExprCode, "\n\t\t\tif id > 0 {\n\t\t\t\t@and id > #{id}\n\t\t\t}\n\t\t", "a.gox.go:9:3", "a.gox.go:9:4"},
+		{SQLExprAtText, "or   name in (#{names})", "a.gox.go:14:3", "a.gox.go:14:4"},
+		{SQLExprDoubleAtQuery, "select * from u where id = #{id}", "a.gox.go:15:9", "a.gox.go:15:12"},
+		{SQLExprParam, "id", "a.gox.go:15:39", "a.gox.go:15:41"},
+	}
+	if len(exprs) != len(tests) {
+		t.Fatalf("got %d expressions, want %d", len(exprs), len(tests))
+	}
+	for i, tt := range tests {
+		e := exprs[i]
+		if e.Type != tt.typ || e.Content != tt.content {
+			t.Errorf("expression %d = %d %q, want %d %q", i, e.Type, e.Content, tt.typ, tt.content)
+			continue
+		}
+		if got := fset.Position(e.Pos()).String(); got != tt.pos {
+			t.Errorf("expression %q at %s, want %s", e.Content, got, tt.pos)
+		}
+		if got := fset.Position(e.ContentPos).String(); got != tt.conPos {
+			t.Errorf("expression %q content at %s, want %s", e.Content, got, tt.conPos)
+		}
+	}
+}
+
+func TestTokensDoNotOverlap(t *testing.T) {
+	tests := []string{
+		"select { if id > 0 { x",
+		"select #{ id and ${ name",
+		"a @{ b #{c} d",
+		"a @@{ b } c { d",
+		"a #{id} { b } c {",
+	}
+	for _, content := range tests {
+		p := NewParser()
+		last := 0
+		for _, tok := range p.tokenizeSQLContent(content) {
+			if tok.Start < last {
+				t.Errorf("%q: token %q starts at %d before the end of the previous token %d", content, tok.Content, tok.Start, last)
+			}
+			last = tok.End
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package parser
+
+import (
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"strings"
+)
+
+// SetStrict 设置是否启用严格的模板语法检查（默认启用）
+// 启用时未闭合或多余的大括号、空的 #{} / ${} 以及无法解析的表达式都会报错，
+// 关闭时这些内容按原来的方式当作 SQL 文本或代码块处理
+func (p *Parser) SetStrict(strict bool) {
+	p.strict = strict
+}
+
+// TemplateError 严格模式下发现的模板语法错误，每一项都带有在 .gox.go 源文件中的行列
+type TemplateError struct {
+	List scanner.ErrorList
+}
+
+func (e *TemplateError) Error() string {
+	var sb strings.Builder
+	sb.WriteString("模板语法错误:")
+	for _, err := range e.List {
+		sb.WriteString("\n\t")
+		sb.WriteString(err.Error())
+	}
+	return sb.String()
+}
+
+// Unwrap 支持通过 errors.As 取得 scanner.ErrorList
+func (e *TemplateError) Unwrap() error {
+	return e.List
+}
+
+// checkTemplate 严格检查位于源文件 offset 处的 SQL 块内容，有问题时返回 *TemplateError
+func (p *Parser) checkTemplate(content string, offset int) error {
+	var errs scanner.ErrorList
+	p.checkTokens(content, p.tokenizeSQLContent(content), offset, &errs)
+	if len(errs) == 0 {
+		return nil
+	}
+	errs.Sort()
+	return &TemplateError{List: errs}
+}
+
+// checkTokens 检查 token 列表，base 为 content 在源文件中的开始位置
+func (p *Parser) checkTokens(content string, tokens []SQLToken, base int, errs *scanner.ErrorList) {
+	report := func(offset int, msg string) {
+		pos := p.filePos(base + offset)
+		if !pos.IsValid() {
+			return
+		}
+		errs.Add(p.file.Position(pos), msg)
+	}
+
+	for _, tok := range tokens {
+		switch tok.Type {
+		case SQLTokenText:
+			// 能够配对的大括号都已经被识别为表达式，文本中剩下的大括号都不配对
+			p.checkStrayBraces(content, tok.Start, tok.End, report)
+
+		case SQLTokenParam, SQLTokenTextExpr:
+			prefix := "#{"
+			if tok.Type == SQLTokenTextExpr {
+				prefix = "${"
+			}
+			contentStart := tok.Start + len(prefix)
+			if strings.TrimSpace(tok.Content) == "" {
+				report(tok.Start, "空的 "+prefix+"} 表达式")
+				continue
+			}
+			if off, msg, ok := p.checkExpr(tok.Content); !ok {
+				report(contentStart+off, prefix+"} 中的表达式无效: "+msg)
+			}
+
+		case SQLTokenAtLine:
+			// @xxx 简写的内容是 SQL 文本，其中同样可以使用 #{} 和 ${}
+			start := tok.Start + 1
+			for start < tok.End && (content[start] == ' ' || content[start] == '\t') {
+				start++
+			}
+			p.checkTokens(tok.Content, p.tokenizeSQLContent(tok.Content), base+start, errs)
+
+		case SQLTokenAtBlock:
+			// 智能作用域模式下跨行的 @ 语句包含 Go 代码，不做检查
+			if !strings.HasPrefix(content[tok.Start:], "@{") {
+				continue
+			}
+			start := tok.Start + len("@{")
+			p.checkTokens(tok.Content, p.tokenizeSQLContent(tok.Content), base+start, errs)
+
+		case SQLTokenDoubleAtBlock:
+			start := tok.Start + len("@@{")
+			p.checkTokens(tok.Content, p.tokenizeSQLContent(tok.Content), base+start, errs)
+		}
+	}
+}
+
+// checkStrayBraces 检查文本中不配对的大括号，转义的大括号以及 \#{}、\${}、\@{} 的大括号是字面文本
+func (p *Parser) checkStrayBraces(content string, start, end int, report func(offset int, msg string)) {
+	literal := 0 // 未闭合的字面 { 的数量
+	for i := start; i < end; i++ {
+		if isEscape(content, i) {
+			i++
+			if content[i] != '{' && content[i] != '}' && i+1 < end && content[i+1] == '{' {
+				literal++
+				i++
+			}
+			continue
+		}
+		switch content[i] {
+		case '{':
+			opener := "{"
+			if i > 0 {
+				switch {
+				case i > 1 && content[i-2:i] == "@@":
+					opener = "@@{"
+				case strings.IndexByte("#$@", content[i-1]) != -1:
+					opener = content[i-1:i] + "{"
+				}
+			}
+			report(i-len(opener)+1, "未闭合的 "+opener)
+			return
+		case '}':
+			if literal > 0 {
+				literal--
+				continue
+			}
+			report(i, "多余的 }")
+		}
+	}
+}
+
+// checkExpr 检查 #{} 或 ${} 中的内容：可以是表达式，也可以是返回值的代码块
+// 返回值 ok 为 false 时 offset 为错误在内容中的位置，msg 为表达式的解析错误
+func (p *Parser) checkExpr(content string) (offset int, msg string, ok bool) {
+	_, err := parser.ParseExpr(content)
+	if err == nil {
+		return 0, "", true
+	}
+
+	// 代码块中可能嵌入 @、#{}、${}，由代码生成时处理，不在这里检查
+	if hasTemplateSyntax(content) {
+		return 0, "", true
+	}
+
+	// 不是表达式时按语句解析，例如 #{ if ok { return id } }
+	src := "package p\nfunc _() {\n" + content + "\n}\n"
+	if _, stmtErr := parser.ParseFile(token.NewFileSet(), "", src, parser.SkipObjectResolution); stmtErr == nil {
+		return 0, "", true
+	}
+
+	if list, isList := err.(scanner.ErrorList); isList && len(list) > 0 {
+		offset = list[0].Pos.Offset
+		if offset > len(content) {
+			offset = len(content)
+		}
+		return offset, list[0].Msg, false
+	}
+	return 0, err.Error(), false
+}
+
+// hasTemplateSyntax 判断代码中字符串、字符字面量和注释之外是否出现 @、# 或 $
+func hasTemplateSyntax(content string) bool {
+	fset := token.NewFileSet()
+	file := fset.AddFile("", -1, len(content))
+
+	var s scanner.Scanner
+	s.Init(file, []byte(content), func(token.Position, string) {}, 0)
+	for {
+		_, tok, lit := s.Scan()
+		switch {
+		case tok == token.EOF:
+			return false
+		case tok == token.ILLEGAL && strings.ContainsAny(lit, "@#$"):
+			return true
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package parser
+
+import (
+	"errors"
+	"go/scanner"
+	"strings"
+	"testing"
+)
+
+// templateSource 返回把 sql 放在第 6 行开头的 .gox.go 源文件
+func templateSource(sql string) string {
+	return "package dao\n\nimport \"github.com/llyb120/gox\"\n\nfunc Find(id int, name string) gox.Query {\n\treturn gox.Sql(`\n" +
+		sql + "\n`)\n}\n"
+}
+
+func TestCheckTemplate(t *testing.T) {
+	tests := []struct {
+		sql  string
+		want []string // 错误位置和信息，为空时模板合法
+	}{
+		{sql: "select * from t where id = #{id}"},
+		{sql: "select * from t where name = #{ \"a@b\" + name }"},
+		{sql: "select * from t\n{\n\tif id > 0 {\n\t\t@and id = #{id}\n\t}\n}"},
+		{sql: "select '\\{\"k\":1\\}', a\\@b, \\#{id}"},
+		{sql: "select #{ func() any { if id > 0 { return id }; return nil }() }"},
+		{
+			sql:  "select { if id > 0 {",
+			want: []string{"a.gox.go:7:8: 未闭合的 {"},
+		},
+		{
+			sql:  "select * from t where id = #{ }",
+			want: []string{"a.gox.go:7:28: 空的 #{} 表达式"},
+		},
+		{
+			sql:  "select ${id +} from t",
+			want: []string{"a.gox.go:7:14: ${} 中的表达式无效"},
+		},
+		{
+			sql:  "select #{ \"a$\" + }",
+			want: []string{"a.gox.go:7:18: #{} 中的表达式无效"},
+		},
+		{
+			sql:  "select #{ 'x' + }",
+			want: []string{"a.gox.go:7:17: #{} 中的表达式无效"},
+		},
+		{
+			sql:  "select } from t",
+			want: []string{"a.gox.go:7:8: 多余的 }"},
+		},
+		{
+			sql:  "select #{} from t\n@and name = ${ }",
+			want: []string{"a.gox.go:7:8: 空的 #{} 表达式", "a.gox.go:8:13: 空的 ${} 表达式"},
+		},
+		{
+			sql:  "select @{ name = #{} }",
+			want: []string{"a.gox.go:7:18: 空的 #{} 表达式"},
+		},
+	}
+	for _, tt := range tests {
+		p := NewParser()
+		_, err := p.ParseFile("a.gox.go", []byte(templateSource(tt.sql)))
+		if len(tt.want) == 0 {
+			if err != nil {
+				t.Errorf("%q: %v", tt.sql, err)
+			}
+			continue
+		}
+
+		var list scanner.ErrorList
+		if !errors.As(err, &list) {
+			t.Errorf("%q: error %v is not a scanner.ErrorList", tt.sql, err)
+			continue
+		}
+		var te *TemplateError
+		if !errors.As(err, &te) {
+			t.Errorf("%q: error %v is not a *TemplateError", tt.sql, err)
+		}
+		if len(list) != len(tt.want) {
+			t.Errorf("%q: got %d errors, want %d:\n%v", tt.sql, len(list), len(tt.want), err)
+			continue
+		}
+		for i, want := range tt.want {
+			if got := list[i].Error(); !strings.HasPrefix(got, want) {
+				t.Errorf("%q: error %d = %q, want prefix %q", tt.sql, i, got, want)
+			}
+		}
+	}
+}
+
+func TestLenientSkipsCheck(t *testing.T) {
+	p := NewParser()
+	p.SetStrict(false)
+	if _, err := p.ParseFile("a.gox.go", []byte(templateSource("select { if id > 0 {"))); err != nil {
+		t.Errorf("lenient mode: %v", err)
+	}
+}
+
+func TestHasTemplateSyntax(t *testing.T) {
+	tests := []struct {
+		code string
+		want bool
+	}{
+		{"id + 1", false},
+		{"\"a$\" + name", false},
+		{"'@'", false},
+		{"`#{x}`", false},
+		{"x // @and", false},
+		{"if ok { @and a = #{b} }", true},
+		{"f(${x})", true},
+	}
+	for _, tt := range tests {
+		if got := hasTemplateSyntax(tt.code); got != tt.want {
+			t.Errorf("hasTemplateSyntax(%q) = %v, want %v", tt.code, got, tt.want)
+		}
+	}
+}
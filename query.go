@@ -72,7 +72,6 @@ func (qb *QueryBuilder) AddText(text any) *QueryBuilder {
 		}
 		return qb.AddText(fmt.Sprintf("%v", text))
 	}
-	return qb
 }
 
 // AddParam 添加参数化查询片段
@@ -0,0 +1,243 @@
+package gox
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Reporter 接收编译过程中的事件，用于输出日志或对接外部系统
+// 编译器保证对同一个 Reporter 的调用是串行的；FileStart 在开始处理文件时实时派发，顺序取决于并发调度，
+// 其余文件事件在全部完成后按源文件路径顺序派发
+type Reporter interface {
+	// FileStart 开始处理一个源文件，在编译该文件之前调用
+	FileStart(source string)
+	// FileSkipped 源文件无需重新编译，reason 为 SkipUpToDate 等原因代码
+	FileSkipped(source, reason string)
+	// FileGenerated 目标文件已生成
+	FileGenerated(source, output string)
+	// FileUnchanged 重新生成的内容与目标文件相同，未写入
+	FileUnchanged(source, output string)
+	// FileStale DryRun 模式下目标文件与生成结果不一致，diff 为 unified diff
+	FileStale(source, output, diff string)
+	// FileFailed 源文件编译失败
+	FileFailed(source string, err error)
+	// FileRemoved 生成的文件被移除（clean 或源文件被删除）
+	FileRemoved(path string)
+	// Summary 一次编译结束后的统计
+	Summary(s Summary)
+}
+
+// 跳过文件的原因代码
+const (
+	SkipUpToDate = "up-to-date" // 目标文件已是最新
+)
+
+// Summary 一次编译的统计信息
+type Summary struct {
+	Total     int `json:"total"`     // 处理的源文件数
+	Generated int `json:"generated"` // 生成的文件数
+	Unchanged int `json:"unchanged"` // 内容未变化的文件数
+	Skipped   int `json:"skipped"`   // 跳过的文件数
+	Stale     int `json:"stale"`     // DryRun 模式下过期的文件数
+	Failed    int `json:"failed"`    // 失败的文件数
+}
+
+// reporter 返回编译器使用的 Reporter，未设置时输出中文文本到标准输出
+func (c *Compiler) reporter() Reporter {
+	if c.Reporter == nil {
+		return NewTextReporter(os.Stdout, "zh")
+	}
+	return c.Reporter
+}
+
+// reportResults 按顺序派发文件结果和统计信息，返回所有失败文件的错误；FileStart 已在处理文件时派发
+func (c *Compiler) reportResults(results []*fileResult) error {
+	rep := c.reporter()
+
+	var (
+		sum  Summary
+		errs CompileErrors
+	)
+	for _, r := range results {
+		sum.Total++
+		switch r.Status {
+		case fileGenerated:
+			sum.Generated++
+			rep.FileGenerated(r.Source, r.Output)
+		case fileUnchanged:
+			sum.Unchanged++
+			rep.FileUnchanged(r.Source, r.Output)
+		case fileSkipped:
+			sum.Skipped++
+			rep.FileSkipped(r.Source, SkipUpToDate)
+		case fileStale:
+			sum.Stale++
+			rep.FileStale(r.Source, r.Output, r.Diff)
+			errs = append(errs, &FileError{File: r.Source, Err: r.Err})
+		case fileFailed:
+			sum.Failed++
+			rep.FileFailed(r.Source, r.Err)
+			errs = append(errs, &FileError{File: r.Source, Err: r.Err})
+		}
+	}
+	rep.Summary(sum)
+	return errs.errorOrNil()
+}
+
+// textMessages 文本输出使用的消息模板
+type textMessages struct {
+	skipped   string
+	generated string
+	unchanged string
+	stale     string
+	failed    string
+	removed   string
+	summary   string
+	reasons   map[string]string
+}
+
+var textLanguages = map[string]*textMessages{
+	"zh": {
+		skipped:   "跳过文件（%s）: %s\n",
+		generated: "生成文件: %s\n",
+		unchanged: "文件未变化: %s\n",
+		stale:     "目标文件已过期: %s\n%s",
+		failed:    "编译失败 %s: %v\n",
+		removed:   "移除文件：%s\n",
+		summary:   "共 %d 个文件，生成 %d 个，未变化 %d 个，跳过 %d 个，过期 %d 个，失败 %d 个\n",
+		reasons: map[string]string{
+			SkipUpToDate: "目标文件已是最新",
+		},
+	},
+	"en": {
+		skipped:   "skipped (%s): %s\n",
+		generated: "generated %s\n",
+		unchanged: "unchanged %s\n",
+		stale:     "stale %s\n%s",
+		failed:    "failed %s: %v\n",
+		removed:   "removed %s\n",
+		summary:   "%d files: %d generated, %d unchanged, %d skipped, %d stale, %d failed\n",
+		reasons: map[string]string{
+			SkipUpToDate: "output is up to date",
+		},
+	},
+}
+
+// TextReporter 输出人类可读的文本日志
+// FileStart 的顺序取决于并发调度，文本日志不输出该事件，保证多次编译的日志可以直接比较
+type TextReporter struct {
+	w   io.Writer
+	msg *textMessages
+}
+
+// NewTextReporter 创建文本 Reporter，lang 支持 "zh"（默认）和 "en"
+func NewTextReporter(w io.Writer, lang string) *TextReporter {
+	msg, ok := textLanguages[lang]
+	if !ok {
+		msg = textLanguages["zh"]
+	}
+	return &TextReporter{w: w, msg: msg}
+}
+
+func (r *TextReporter) FileStart(source string) {}
+
+func (r *TextReporter) FileSkipped(source, reason string) {
+	if text, ok := r.msg.reasons[reason]; ok {
+		reason = text
+	}
+	fmt.Fprintf(r.w, r.msg.skipped, reason, source)
+}
+
+func (r *TextReporter) FileGenerated(source, output string) {
+	fmt.Fprintf(r.w, r.msg.generated, output)
+}
+
+func (r *TextReporter) FileUnchanged(source, output string) {
+	fmt.Fprintf(r.w, r.msg.unchanged, output)
+}
+
+func (r *TextReporter) FileStale(source, output, diff string) {
+	fmt.Fprintf(r.w, r.msg.stale, output, diff)
+}
+
+func (r *TextReporter) FileFailed(source string, err error) {
+	fmt.Fprintf(r.w, r.msg.failed, source, err)
+}
+
+func (r *TextReporter) FileRemoved(path string) {
+	fmt.Fprintf(r.w, r.msg.removed, path)
+}
+
+func (r *TextReporter) Summary(s Summary) {
+	fmt.Fprintf(r.w, r.msg.summary, s.Total, s.Generated, s.Unchanged, s.Skipped, s.Stale, s.Failed)
+}
+
+// QuietReporter 忽略所有事件，错误仍然通过 Compile 的返回值获取
+type QuietReporter struct{}
+
+func (QuietReporter) FileStart(source string)               {}
+func (QuietReporter) FileSkipped(source, reason string)     {}
+func (QuietReporter) FileGenerated(source, output string)   {}
+func (QuietReporter) FileUnchanged(source, output string)   {}
+func (QuietReporter) FileStale(source, output, diff string) {}
+func (QuietReporter) FileFailed(source string, err error)   {}
+func (QuietReporter) FileRemoved(path string)               {}
+func (QuietReporter) Summary(s Summary)                     {}
+
+// JSONReporter 每个事件输出一行 JSON，便于编辑器和 CI 解析
+type JSONReporter struct {
+	enc *json.Encoder
+}
+
+// NewJSONReporter 创建 JSON Lines 格式的 Reporter
+func NewJSONReporter(w io.Writer) *JSONReporter {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return &JSONReporter{enc: enc}
+}
+
+// jsonEvent JSON 输出的事件结构
+type jsonEvent struct {
+	Event  string `json:"event"`
+	Source string `json:"source,omitempty"`
+	Output string `json:"output,omitempty"`
+	Path   string `json:"path,omitempty"`
+	Reason string `json:"reason,omitempty"`
+	Diff   string `json:"diff,omitempty"`
+	Error  string `json:"error,omitempty"`
+	*Summary
+}
+
+func (r *JSONReporter) FileStart(source string) {
+	r.enc.Encode(jsonEvent{Event: "start", Source: source})
+}
+
+func (r *JSONReporter) FileSkipped(source, reason string) {
+	r.enc.Encode(jsonEvent{Event: "skip", Source: source, Reason: reason})
+}
+
+func (r *JSONReporter) FileGenerated(source, output string) {
+	r.enc.Encode(jsonEvent{Event: "generate", Source: source, Output: output})
+}
+
+func (r *JSONReporter) FileUnchanged(source, output string) {
+	r.enc.Encode(jsonEvent{Event: "unchanged", Source: source, Output: output})
+}
+
+func (r *JSONReporter) FileStale(source, output, diff string) {
+	r.enc.Encode(jsonEvent{Event: "stale", Source: source, Output: output, Diff: diff})
+}
+
+func (r *JSONReporter) FileFailed(source string, err error) {
+	r.enc.Encode(jsonEvent{Event: "fail", Source: source, Error: err.Error()})
+}
+
+func (r *JSONReporter) FileRemoved(path string) {
+	r.enc.Encode(jsonEvent{Event: "remove", Path: path})
+}
+
+func (r *JSONReporter) Summary(s Summary) {
+	r.enc.Encode(jsonEvent{Event: "summary", Summary: &s})
+}
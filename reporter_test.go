@@ -0,0 +1,69 @@
+package gox
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// recordReporter 记录事件，FileStart 时同时记录目标文件是否已经存在
+type recordReporter struct {
+	QuietReporter
+	mu      sync.Mutex
+	events  []string
+	started map[string]bool // 源文件 -> FileStart 时目标文件是否存在
+}
+
+func (r *recordReporter) FileStart(source string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, "start "+filepath.Base(source))
+	r.started[source] = fileExists(strings.TrimSuffix(source, ".gox.go") + "_gen.go")
+}
+
+func (r *recordReporter) FileGenerated(source, output string) {
+	r.events = append(r.events, "generate "+filepath.Base(source))
+}
+
+func TestFileStartBeforeProcessing(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a.gox.go":     testSource("dao"),
+		"b.gox.go":     testSource("dao"),
+		"sub/c.gox.go": testSource("sub"),
+	})
+
+	rep := &recordReporter{started: make(map[string]bool)}
+	c := newTestCompiler(dir)
+	c.Reporter = rep
+	c.Concurrency = 1
+	if err := c.Compile(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "start a.gox.go,start b.gox.go,start c.gox.go,generate a.gox.go,generate b.gox.go,generate c.gox.go"
+	if got := strings.Join(rep.events, ","); got != want {
+		t.Errorf("events = %s, want %s", got, want)
+	}
+	if len(rep.started) != 3 {
+		t.Errorf("FileStart called for %d files, want 3", len(rep.started))
+	}
+	for source, existed := range rep.started {
+		if existed {
+			t.Errorf("FileStart(%s) called after the output was written", source)
+		}
+	}
+}
+
+func TestTextReporterStableOutput(t *testing.T) {
+	var buf bytes.Buffer
+	r := NewTextReporter(&buf, "en")
+	r.FileStart("b.gox.go")
+	r.FileStart("a.gox.go")
+	r.FileGenerated("a.gox.go", "a_gen.go")
+	if got := buf.String(); got != "generated a_gen.go\n" {
+		t.Errorf("text output = %q", got)
+	}
+}
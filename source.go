@@ -0,0 +1,164 @@
+package gox
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/llyb120/gox/parser"
+)
+
+// Options 内存编译的选项，含义与 Compiler 中的同名字段相同
+type Options struct {
+	// SourceName 写入生成文件头和 //line 指令的源文件名，默认为 filename 的文件名部分
+	SourceName string
+
+	DebugMode        bool
+	NoLineDirectives bool
+	SmartScope       bool
+	Dialect          Dialect
+	Header           string
+	Lenient          bool
+
+	// StampBuildTag 只用于从生成文件的构建约束中去掉该标签，CompileSource 不会修改源文件
+	StampBuildTag string
+
+	// 以下选项只用于 CompileFS
+	SourceSuffix string   // 源文件后缀，默认 .gox.go
+	OutputSuffix string   // 生成文件后缀，默认 _gen.go
+	Include      []string // 只编译匹配的源文件（gitignore 语法，相对 root）
+	Exclude      []string // 跳过匹配的文件和目录（gitignore 语法，相对 root）
+}
+
+// CompileSource 在内存中编译一个 .gox.go 源文件，返回生成的 Go 代码，不读写任何文件
+// filename 用于错误信息中的文件位置
+func CompileSource(filename string, src []byte, opts Options) ([]byte, error) {
+	if err := checkDialect(opts.Dialect); err != nil {
+		return nil, err
+	}
+
+	sourceName := opts.SourceName
+	if sourceName == "" {
+		sourceName = filepath.Base(filename)
+	}
+
+	// 解析并生成目标文件
+	p := parser.NewParser()
+	p.SetDebugMode(opts.DebugMode) // 设置调试模式
+	p.SetSmartScope(opts.SmartScope)
+	p.SetStrict(!opts.Lenient)
+	if !opts.NoLineDirectives {
+		p.SetLineDirectives(sourceName)
+	}
+	goxFile, err := p.ParseFile(filename, src)
+	if err != nil {
+		return nil, fmt.Errorf("解析文件失败: %w", err)
+	}
+
+	// 生成Go代码
+	generator := parser.NewGenerator()
+	generator.SetSourceName(sourceName)
+	generator.SetHeader(opts.Header)
+	generator.SetStampTag(opts.StampBuildTag)
+	generated, err := generator.GenerateFile(goxFile)
+	if err != nil {
+		return nil, fmt.Errorf("生成代码失败: %w", err)
+	}
+
+	return generated, nil
+}
+
+// OutputSink 接收 CompileFS 生成的文件
+type OutputSink interface {
+	// WriteFile 写入一个生成文件，name 为 slash 分隔的相对路径
+	WriteFile(name string, data []byte) error
+}
+
+// MapSink 把生成文件保存在内存中，键为生成文件的相对路径
+type MapSink map[string][]byte
+
+func (s MapSink) WriteFile(name string, data []byte) error {
+	s[name] = append([]byte(nil), data...)
+	return nil
+}
+
+// DirSink 把生成文件写入磁盘目录，内容未变化的文件不改写
+type DirSink string
+
+func (d DirSink) WriteFile(name string, data []byte) error {
+	_, err := writeFileAtomic(filepath.Join(string(d), filepath.FromSlash(name)), data, 0644)
+	return err
+}
+
+// CompileFS 编译 fsys 中 root 目录下的所有 .gox.go 文件，生成文件写在对应源文件旁边（相对 fsys 的路径）并交给 sink
+// 与目录编译一样跳过隐藏目录以及 vendor、testdata、node_modules，并读取各级目录下的 .goxignore；
+// 单个文件失败不会中断其他文件的编译，所有失败的文件以 CompileErrors 的形式一起返回
+func CompileFS(fsys fs.FS, root string, sink OutputSink, opts Options) error {
+	c := &Compiler{
+		SourceSuffix: opts.SourceSuffix,
+		OutputSuffix: opts.OutputSuffix,
+		Include:      opts.Include,
+		Exclude:      opts.Exclude,
+	}
+	if c.sourceSuffix() == c.outputSuffix() || !strings.HasSuffix(c.outputSuffix(), ".go") {
+		return fmt.Errorf("无效的文件后缀: %q -> %q", c.sourceSuffix(), c.outputSuffix())
+	}
+	if err := checkDialect(opts.Dialect); err != nil {
+		return err
+	}
+
+	filter, err := c.newSourceFilter(filepath.FromSlash(root))
+	if err != nil {
+		return err
+	}
+	filter.readFile = func(name string) ([]byte, error) {
+		return fs.ReadFile(fsys, filepath.ToSlash(name))
+	}
+
+	var files []string
+	err = fs.WalkDir(fsys, root, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if filter.skip(filepath.FromSlash(name), true) {
+				return fs.SkipDir
+			}
+			return filter.enterDir(filepath.FromSlash(name))
+		}
+		if strings.HasSuffix(name, c.sourceSuffix()) && !filter.skip(filepath.FromSlash(name), false) {
+			files = append(files, name)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	sort.Strings(files)
+
+	var errs CompileErrors
+	for _, name := range files {
+		output := strings.TrimSuffix(name, c.sourceSuffix()) + c.outputSuffix()
+
+		src, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			errs = append(errs, &FileError{File: name, Err: fmt.Errorf("读取文件失败 %s: %v", name, err)})
+			continue
+		}
+
+		fileOpts := opts
+		fileOpts.SourceName = path.Base(name)
+		generated, err := CompileSource(name, src, fileOpts)
+		if err != nil {
+			errs = append(errs, &FileError{File: name, Err: err})
+			continue
+		}
+		if err := sink.WriteFile(output, generated); err != nil {
+			errs = append(errs, &FileError{File: name, Err: fmt.Errorf("写入文件失败 %s: %v", output, err)})
+		}
+	}
+	return errs.errorOrNil()
+}
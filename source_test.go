@@ -0,0 +1,43 @@
+package gox
+
+import (
+	"errors"
+	"go/scanner"
+	"path/filepath"
+	"testing"
+
+	"github.com/llyb120/gox/parser"
+)
+
+// badSource 的 SQL 模板中有一个空的 #{}
+const badSource = "package dao\n\nimport \"github.com/llyb120/gox\"\n\n" +
+	"func Find() gox.Query {\n\treturn gox.Sql(`select * from t where id = #{}`)\n}\n"
+
+func TestTemplateErrorUnwrap(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"dao/user.gox.go": badSource})
+
+	_, sourceErr := CompileSource("user.gox.go", []byte(badSource), Options{})
+	compileErr := newTestCompiler(dir).Compile()
+
+	for name, err := range map[string]error{"CompileSource": sourceErr, "Compile": compileErr} {
+		var te *parser.TemplateError
+		if !errors.As(err, &te) {
+			t.Errorf("%s: errors.As(*parser.TemplateError) failed for %v", name, err)
+			continue
+		}
+		var list scanner.ErrorList
+		if !errors.As(err, &list) || len(list) != 1 {
+			t.Errorf("%s: errors.As(scanner.ErrorList) = %v", name, list)
+			continue
+		}
+		if pos := list[0].Pos; filepath.Base(pos.Filename) != "user.gox.go" || pos.Line != 6 || pos.Column != 45 {
+			t.Errorf("%s: error at %s, want user.gox.go:6:45", name, pos)
+		}
+	}
+
+	var fe *FileError
+	if !errors.As(compileErr, &fe) {
+		t.Errorf("Compile: errors.As(*FileError) failed for %v", compileErr)
+	}
+}
@@ -0,0 +1,35 @@
+package gox
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStampBuildTagNotCopied(t *testing.T) {
+	dir := t.TempDir()
+	src := strings.TrimPrefix(testSource("dao"), "//go:build ignore\n\n")
+	writeFiles(t, dir, map[string]string{"dao/user.gox.go": src})
+
+	c := newTestCompiler(dir)
+	c.StampBuildTag = "mytag"
+	if err := c.Compile(); err != nil {
+		t.Fatal(err)
+	}
+
+	stamped, err := os.ReadFile(filepath.Join(dir, "dao", "user.gox.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(stamped), "//go:build mytag\n") {
+		t.Errorf("source was not stamped:\n%s", stamped)
+	}
+	generated, err := os.ReadFile(filepath.Join(dir, "dao", "user_gen.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(generated), "//go:build") {
+		t.Errorf("generated file inherits the stamp tag:\n%s", generated)
+	}
+}
@@ -0,0 +1,312 @@
+package gox
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// parseTOML 解析配置文件使用的 TOML 子集：顶层的 key = value，
+// 值支持字符串（包括多行字符串和字面量字符串）、整数、布尔值以及数组，# 开始注释；不支持表
+func parseTOML(data string) (map[string]any, error) {
+	p := &tomlParser{s: data, line: 1}
+	result := make(map[string]any)
+
+	for {
+		p.skipBlank()
+		if p.eof() {
+			return result, nil
+		}
+		if p.peek() == '[' {
+			return nil, p.errorf("不支持表（[table]），所有配置项都应写在顶层")
+		}
+
+		key, err := p.key()
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		if !p.consume('=') {
+			return nil, p.errorf("配置项 %s 缺少 =", key)
+		}
+		p.skipSpace()
+
+		value, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := result[key]; ok {
+			return nil, p.errorf("重复的配置项 %s", key)
+		}
+		result[key] = value
+
+		p.skipSpace()
+		p.skipComment()
+		if !p.eof() && !p.newline() {
+			return nil, p.errorf("配置项 %s 的值后面有多余的内容", key)
+		}
+	}
+}
+
+// tomlParser TOML 子集的解析状态
+type tomlParser struct {
+	s    string
+	pos  int
+	line int
+}
+
+func (p *tomlParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("第 %d 行: %s", p.line, fmt.Sprintf(format, args...))
+}
+
+func (p *tomlParser) eof() bool {
+	return p.pos >= len(p.s)
+}
+
+func (p *tomlParser) peek() byte {
+	return p.s[p.pos]
+}
+
+// consume 当前字符为 c 时跳过并返回 true
+func (p *tomlParser) consume(c byte) bool {
+	if !p.eof() && p.peek() == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// newline 跳过一个换行符
+func (p *tomlParser) newline() bool {
+	if strings.HasPrefix(p.s[p.pos:], "\r\n") {
+		p.pos += 2
+	} else if !p.consume('\n') {
+		return false
+	}
+	p.line++
+	return true
+}
+
+// skipSpace 跳过行内的空格和制表符
+func (p *tomlParser) skipSpace() {
+	for !p.eof() && (p.peek() == ' ' || p.peek() == '\t') {
+		p.pos++
+	}
+}
+
+// skipComment 跳过到行尾的注释
+func (p *tomlParser) skipComment() {
+	if !p.eof() && p.peek() == '#' {
+		for !p.eof() && p.peek() != '\n' {
+			p.pos++
+		}
+	}
+}
+
+// skipBlank 跳过空白、注释和换行
+func (p *tomlParser) skipBlank() {
+	for {
+		p.skipSpace()
+		p.skipComment()
+		if !p.newline() {
+			return
+		}
+	}
+}
+
+// key 解析裸键名或带引号的键名
+func (p *tomlParser) key() (string, error) {
+	if !p.eof() && (p.peek() == '"' || p.peek() == '\'') {
+		v, err := p.value()
+		if err != nil {
+			return "", err
+		}
+		return v.(string), nil
+	}
+
+	start := p.pos
+	for !p.eof() {
+		c := p.peek()
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-') {
+			break
+		}
+		p.pos++
+	}
+	if p.pos == start {
+		return "", p.errorf("缺少配置项名称")
+	}
+	return p.s[start:p.pos], nil
+}
+
+// value 解析一个值
+func (p *tomlParser) value() (any, error) {
+	if p.eof() {
+		return nil, p.errorf("缺少值")
+	}
+
+	rest := p.s[p.pos:]
+	switch {
+	case strings.HasPrefix(rest, `"""`):
+		return p.multilineString(`"""`, true)
+	case strings.HasPrefix(rest, "'''"):
+		return p.multilineString("'''", false)
+	case rest[0] == '"':
+		return p.basicString()
+	case rest[0] == '\'':
+		end := strings.IndexAny(rest[1:], "'\n")
+		if end == -1 || rest[1+end] != '\'' {
+			return nil, p.errorf("字符串缺少结束的 '")
+		}
+		p.pos += end + 2
+		return rest[1 : 1+end], nil
+	case rest[0] == '[':
+		return p.array()
+	case strings.HasPrefix(rest, "true"):
+		p.pos += 4
+		return true, nil
+	case strings.HasPrefix(rest, "false"):
+		p.pos += 5
+		return false, nil
+	}
+
+	// 整数，允许使用 _ 分隔数字
+	start := p.pos
+	for !p.eof() && strings.IndexByte("+-0123456789_", p.peek()) != -1 {
+		p.pos++
+	}
+	n, err := strconv.ParseInt(strings.ReplaceAll(p.s[start:p.pos], "_", ""), 10, 64)
+	if p.pos == start || err != nil {
+		return nil, p.errorf("无法识别的值 %q", firstLine(rest))
+	}
+	return n, nil
+}
+
+// basicString 解析单行的双引号字符串
+func (p *tomlParser) basicString() (string, error) {
+	i := p.pos + 1
+	for i < len(p.s) && p.s[i] != '"' && p.s[i] != '\n' {
+		if p.s[i] == '\\' {
+			i++
+		}
+		i++
+	}
+	if i >= len(p.s) || p.s[i] != '"' {
+		return "", p.errorf("字符串缺少结束的 \"")
+	}
+	s, err := p.unescape(p.s[p.pos+1 : i])
+	p.pos = i + 1
+	return s, err
+}
+
+// multilineString 解析三引号包裹的多行字符串，紧跟开始引号的换行会被去掉
+func (p *tomlParser) multilineString(quote string, escape bool) (string, error) {
+	start := p.pos + len(quote)
+	end := strings.Index(p.s[start:], quote)
+	if end == -1 {
+		return "", p.errorf("多行字符串缺少结束的 %s", quote)
+	}
+	raw := p.s[start : start+end]
+	p.line += strings.Count(raw, "\n")
+	p.pos = start + end + len(quote)
+
+	raw = strings.TrimPrefix(strings.TrimPrefix(raw, "\r"), "\n")
+	if !escape {
+		return raw, nil
+	}
+	return p.unescape(raw)
+}
+
+// unescape 处理双引号字符串中的转义字符
+func (p *tomlParser) unescape(s string) (string, error) {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			sb.WriteByte(s[i])
+			continue
+		}
+		i++
+		if i >= len(s) {
+			return "", p.errorf("字符串以 \\ 结尾")
+		}
+		switch s[i] {
+		case 'n':
+			sb.WriteByte('\n')
+		case 't':
+			sb.WriteByte('\t')
+		case 'r':
+			sb.WriteByte('\r')
+		case '"':
+			sb.WriteByte('"')
+		case '\\':
+			sb.WriteByte('\\')
+		case 'u', 'U':
+			size := 4
+			if s[i] == 'U' {
+				size = 8
+			}
+			if i+1+size > len(s) {
+				return "", p.errorf("无效的转义字符 \\%c", s[i])
+			}
+			code, err := strconv.ParseUint(s[i+1:i+1+size], 16, 32)
+			if err != nil || !utf8.ValidRune(rune(code)) {
+				return "", p.errorf("无效的转义字符 \\%s", s[i:i+1+size])
+			}
+			sb.WriteRune(rune(code))
+			i += size
+		case ' ', '\t', '\r', '\n':
+			// 行尾的 \ 去掉换行以及下一行开头的空白
+			j := i
+			for j < len(s) && (s[j] == ' ' || s[j] == '\t') {
+				j++
+			}
+			if j < len(s) && s[j] != '\n' && s[j] != '\r' {
+				return "", p.errorf("无效的转义字符 \\%c", s[i])
+			}
+			for j < len(s) && strings.IndexByte(" \t\r\n", s[j]) != -1 {
+				j++
+			}
+			i = j - 1
+		default:
+			return "", p.errorf("无效的转义字符 \\%c", s[i])
+		}
+	}
+	return sb.String(), nil
+}
+
+// array 解析数组，元素之间可以换行和写注释，允许末尾的逗号
+func (p *tomlParser) array() ([]any, error) {
+	p.pos++ // 跳过 [
+	values := []any{}
+	for {
+		p.skipBlank()
+		if p.eof() {
+			return nil, p.errorf("数组缺少结束的 ]")
+		}
+		if p.consume(']') {
+			return values, nil
+		}
+
+		v, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+
+		p.skipBlank()
+		if p.consume(']') {
+			return values, nil
+		}
+		if !p.consume(',') {
+			return nil, p.errorf("数组元素之间缺少 ,")
+		}
+	}
+}
+
+// firstLine 返回文本的第一行，用于错误信息
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i != -1 {
+		return s[:i]
+	}
+	return s
+}
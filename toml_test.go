@@ -0,0 +1,95 @@
+package gox
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseTOML(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want map[string]any
+	}{
+		{"empty", "", map[string]any{}},
+		{"comments", "# 注释\n\n  # 缩进的注释\nsrc = \"dao\" # 行尾注释\n", map[string]any{"src": "dao"}},
+		{"crlf", "src = \"dao\"\r\nlenient = true\r\n", map[string]any{"src": "dao", "lenient": true}},
+		{"bool and int", "smart_scope = false\nconcurrency = 1_000\nn = -3\n", map[string]any{"smart_scope": false, "concurrency": int64(1000), "n": int64(-3)}},
+		{"quoted key", "\"source_suffix\" = \".gox.go\"\n'dest' = 'out'\n", map[string]any{"source_suffix": ".gox.go", "dest": "out"}},
+		{"escapes", `header = "a\tb\n\"c\" \\ \u00e9\U0001F600"`, map[string]any{"header": "a\tb\n\"c\" \\ é😀"}},
+		{"literal string", `dest = 'C:\out\#{x}'`, map[string]any{"dest": `C:\out\#{x}`}},
+		{"hash in string", `header = "# not a comment" # comment`, map[string]any{"header": "# not a comment"}},
+		{"multiline", "header = \"\"\"\nline1\nline2\\\n    continued\"\"\"\n", map[string]any{"header": "line1\nline2continued"}},
+		{"multiline literal", "header = '''\nraw \\n\n'''\n", map[string]any{"header": "raw \\n\n"}},
+		{"array", `exclude = ["a/", 'b/']`, map[string]any{"exclude": []any{"a/", "b/"}}},
+		{"empty array", "include = []", map[string]any{"include": []any{}}},
+		{"multiline array", "exclude = [\n  \"a/\", # 注释\n  \"b/\",\n]\n", map[string]any{"exclude": []any{"a/", "b/"}}},
+		{"nested array", "x = [[1, 2], [true]]", map[string]any{"x": []any{[]any{int64(1), int64(2)}, []any{true}}}},
+	}
+	for _, tt := range tests {
+		got, err := parseTOML(tt.in)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: parseTOML = %#v, want %#v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseTOMLErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string // 错误信息中应包含的内容
+	}{
+		{"table", "[build]\nsrc = \"dao\"\n", "第 1 行: 不支持表"},
+		{"missing equals", "src \"dao\"\n", "缺少 ="},
+		{"missing key", "= 1\n", "缺少配置项名称"},
+		{"missing value", "src =", "缺少值"},
+		{"duplicate key", "src = \"a\"\nsrc = \"b\"\n", "第 2 行: 重复的配置项 src"},
+		{"trailing content", "lenient = true false\n", "多余的内容"},
+		{"unterminated string", "src = \"dao\nx = 1\n", "缺少结束的 \""},
+		{"unterminated literal", "src = 'dao\n", "缺少结束的 '"},
+		{"unterminated multiline", "header = \"\"\"\nabc\n", "缺少结束的 \"\"\""},
+		{"bad escape", `src = "a\qb"`, `无效的转义字符 \q`},
+		{"bad unicode", `src = "\u12"`, "无效的转义字符"},
+		{"surrogate", `src = "\uD800"`, "无效的转义字符"},
+		{"unterminated array", "exclude = [\"a\",\n", "缺少结束的 ]"},
+		{"missing comma", "exclude = [\"a\" \"b\"]", "缺少 ,"},
+		{"bad value", "concurrency = four\n", "无法识别的值"},
+		{"line number", "a = 1\n\n# c\nb = \"\"\"\nx\n\"\"\"\nc = ?\n", "第 7 行"},
+	}
+	for _, tt := range tests {
+		_, err := parseTOML(tt.in)
+		if err == nil {
+			t.Errorf("%s: parseTOML succeeded, want error containing %q", tt.name, tt.want)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: error %q does not contain %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestLoadConfigTOML(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"gox.toml": "src = \"dao\"\nconcurrency = 4\nexclude = [\"legacy/\"]\n",
+		"bad.toml": "unknown = 1\n",
+	})
+
+	cfg, err := LoadConfig(filepath.Join(dir, "gox.toml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Src != "dao" || cfg.Concurrency != 4 || !reflect.DeepEqual(cfg.Exclude, []string{"legacy/"}) {
+		t.Errorf("LoadConfig = %+v", cfg)
+	}
+	if _, err := LoadConfig(filepath.Join(dir, "bad.toml")); err == nil {
+		t.Error("LoadConfig accepted an unknown key")
+	}
+}
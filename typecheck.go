@@ -0,0 +1,326 @@
+package gox
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/build"
+	goparser "go/parser"
+	"go/scanner"
+	"go/token"
+	"go/types"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// maxTypeErrors 每个源文件最多报告的类型检查错误数，与 go build 一致
+const maxTypeErrors = 10
+
+// typeCheck 对本次生成文件所在的包做类型检查，错误记录到对应源文件的编译结果中
+// 导入的包由 sourceImporter 从本地源码加载，不编译依赖，也不会下载模块
+func (c *Compiler) typeCheck(results []*fileResult) {
+	// 包目录 -> 该包中本次生成的文件
+	pkgs := make(map[string][]*fileResult)
+	for _, r := range results {
+		if r == nil || r.Code == nil || r.Status == fileFailed {
+			continue
+		}
+		dir := filepath.Dir(r.Output)
+		if c.overlayMode() {
+			// overlay 模式下生成文件在编译时代替源文件，属于源文件所在的包
+			dir = filepath.Dir(r.Source)
+		}
+		pkgs[dir] = append(pkgs[dir], r)
+	}
+
+	dirs := make([]string, 0, len(pkgs))
+	for dir := range pkgs {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+
+	// 所有包共用文件集和导入器，导入过的包只加载一次
+	fset := token.NewFileSet()
+	imp := newSourceImporter(fset)
+	for _, dir := range dirs {
+		c.checkPackage(fset, imp, dir, pkgs[dir])
+	}
+}
+
+// checkPackage 类型检查一个包，包中的生成文件使用内存中的生成结果，其他文件从磁盘读取
+func (c *Compiler) checkPackage(fset *token.FileSet, imp types.Importer, dir string, rs []*fileResult) {
+	// 文件路径 -> 生成的代码，overlay 模式下以源文件路径代替
+	generated := make(map[string]*fileResult, len(rs))
+	for _, r := range rs {
+		if c.overlayMode() {
+			generated[r.Source] = r
+		} else {
+			generated[r.Output] = r
+		}
+	}
+
+	ctx := build.Default
+	ctx.OpenFile = func(path string) (io.ReadCloser, error) {
+		if r, ok := generated[path]; ok {
+			return io.NopCloser(bytes.NewReader(r.Code)), nil
+		}
+		return os.Open(path)
+	}
+
+	bp, err := ctx.ImportDir(dir, 0)
+	var noGo *build.NoGoError
+	if err != nil && !errors.As(err, &noGo) {
+		c.failTypeCheck(rs[0], fmt.Errorf("读取包失败 %s: %v", dir, err))
+		return
+	}
+
+	// 按构建约束选出参与编译的文件，DryRun 模式下尚未写入磁盘的生成文件需要单独判断
+	names := append(append([]string(nil), bp.GoFiles...), bp.CgoFiles...)
+	for path := range generated {
+		name := filepath.Base(path)
+		if slices.Contains(names, name) {
+			continue
+		}
+		if match, err := ctx.MatchFile(dir, name); err == nil && match {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return
+	}
+	sort.Strings(names)
+
+	errs := make(map[*fileResult]TypeErrors)
+	report := func(r *fileResult, pos token.Position, msg string) {
+		if len(errs[r]) < maxTypeErrors {
+			errs[r] = append(errs[r], &TypeError{Pos: pos, Msg: msg})
+		}
+	}
+
+	var files []*ast.File
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		var src any
+		owner := rs[0]
+		if r, ok := generated[path]; ok {
+			src, owner = r.Code, r
+		}
+		f, err := goparser.ParseFile(fset, path, src, goparser.ParseComments|goparser.SkipObjectResolution)
+		var list scanner.ErrorList
+		if errors.As(err, &list) {
+			for _, e := range list {
+				report(owner, e.Pos, e.Msg)
+			}
+		} else if err != nil {
+			c.failTypeCheck(rs[0], err)
+			return
+		}
+		if f != nil {
+			files = append(files, f)
+		}
+	}
+
+	conf := types.Config{
+		Importer:    imp,
+		FakeImportC: true,
+		Error: func(err error) {
+			if te, ok := err.(types.Error); ok {
+				report(c.typeErrorOwner(fset, te.Pos, rs), fset.Position(te.Pos), te.Msg)
+			}
+		},
+	}
+	path := bp.ImportPath
+	if path == "" || path == "." {
+		path = bp.Name
+	}
+	conf.Check(path, fset, files, nil)
+
+	for r, list := range errs {
+		sort.SliceStable(list, func(i, j int) bool {
+			a, b := list[i].Pos, list[j].Pos
+			if a.Filename != b.Filename {
+				return a.Filename < b.Filename
+			}
+			if a.Line != b.Line {
+				return a.Line < b.Line
+			}
+			return a.Column < b.Column
+		})
+		c.failTypeCheck(r, list)
+	}
+}
+
+// typeErrorOwner 返回错误所属的源文件编译结果：错误位于某个生成文件（或通过 //line 指令指向其源文件）时归属该文件，
+// 位于包中其他文件时归属第一个生成文件
+func (c *Compiler) typeErrorOwner(fset *token.FileSet, pos token.Pos, rs []*fileResult) *fileResult {
+	mapped := fset.Position(pos).Filename
+	raw := fset.PositionFor(pos, false).Filename
+	for _, r := range rs {
+		if mapped == r.Source || raw == r.Output || raw == r.Source {
+			return r
+		}
+	}
+	return rs[0]
+}
+
+// failTypeCheck 将类型检查失败的文件标记为失败，并使其在下次增量编译时重新生成和检查
+func (c *Compiler) failTypeCheck(r *fileResult, err error) {
+	r.Status = fileFailed
+	r.Diff = ""
+	r.Err = err
+	if c.manifest != nil {
+		c.manifest.forget(r.Source)
+	}
+	if c.overlay != nil {
+		c.overlay.update(r)
+	}
+}
+
+// sourceImporter 从本地源码加载导入的包，只做类型检查所需的声明检查（忽略函数体）
+// 标准库直接从 GOROOT 读取；其他包通过离线的 go list 定位（GOPROXY=off、GOTOOLCHAIN=local），
+// 只使用模块缓存、vendor 目录和 replace 指向的本地目录，不会下载任何模块或工具链
+type sourceImporter struct {
+	fset     *token.FileSet
+	ctx      build.Context
+	sizes    types.Sizes
+	packages map[string]*types.Package // 包目录 -> 已加载的包，nil 表示正在加载
+	dirs     map[string][2]string      // 非标准库的导入路径 -> 包目录和完整导入路径
+}
+
+func newSourceImporter(fset *token.FileSet) *sourceImporter {
+	ctx := build.Default
+	// 依赖不经过 cgo 处理，按禁用 cgo 选择文件；设置 OpenFile 使 go/build 不再自行调用 go list
+	ctx.CgoEnabled = false
+	ctx.OpenFile = func(path string) (io.ReadCloser, error) { return os.Open(path) }
+	return &sourceImporter{
+		fset:     fset,
+		ctx:      ctx,
+		sizes:    types.SizesFor("gc", ctx.GOARCH),
+		packages: make(map[string]*types.Package),
+		dirs:     make(map[string][2]string),
+	}
+}
+
+func (imp *sourceImporter) Import(path string) (*types.Package, error) {
+	return imp.ImportFrom(path, ".", 0)
+}
+
+// ImportFrom 加载从 srcDir 目录中导入的 path 包
+func (imp *sourceImporter) ImportFrom(path, srcDir string, _ types.ImportMode) (*types.Package, error) {
+	if path == "unsafe" {
+		return types.Unsafe, nil
+	}
+	dir, importPath, err := imp.findPackage(path, srcDir)
+	if err != nil {
+		return nil, err
+	}
+	if pkg, ok := imp.packages[dir]; ok {
+		if pkg == nil {
+			return nil, fmt.Errorf("导入循环: %s", importPath)
+		}
+		return pkg, nil
+	}
+	imp.packages[dir] = nil
+
+	bp, err := imp.ctx.ImportDir(dir, 0)
+	if err != nil {
+		delete(imp.packages, dir)
+		return nil, err
+	}
+	var files []*ast.File
+	for _, name := range bp.GoFiles {
+		f, err := goparser.ParseFile(imp.fset, filepath.Join(dir, name), nil, goparser.SkipObjectResolution)
+		if err != nil {
+			delete(imp.packages, dir)
+			return nil, err
+		}
+		files = append(files, f)
+	}
+
+	var firstErr error
+	conf := types.Config{
+		Importer:         imp,
+		Sizes:            imp.sizes,
+		IgnoreFuncBodies: true,
+		FakeImportC:      true,
+		Error: func(err error) {
+			if firstErr == nil {
+				firstErr = err
+			}
+		},
+	}
+	pkg, _ := conf.Check(importPath, imp.fset, files, nil)
+	if firstErr != nil {
+		delete(imp.packages, dir)
+		return nil, fmt.Errorf("类型检查 %s 失败: %v", importPath, firstErr)
+	}
+	imp.packages[dir] = pkg
+	return pkg, nil
+}
+
+// findPackage 返回导入路径对应的包目录和完整导入路径
+func (imp *sourceImporter) findPackage(path, srcDir string) (dir, importPath string, err error) {
+	goroot := filepath.Join(imp.ctx.GOROOT, "src")
+	if imp.ctx.GOROOT != "" {
+		// 标准库内部的导入优先使用 GOROOT/src/vendor 中的副本
+		if rel, err := filepath.Rel(goroot, srcDir); err == nil && filepath.IsAbs(srcDir) && !strings.HasPrefix(rel, "..") {
+			if dir := filepath.Join(goroot, "vendor", path); isDir(dir) {
+				return dir, "vendor/" + path, nil
+			}
+		}
+		if dir := filepath.Join(goroot, path); isDir(dir) {
+			return dir, path, nil
+		}
+	}
+
+	if found, ok := imp.dirs[path]; ok {
+		return found[0], found[1], nil
+	}
+	dir, importPath, err = imp.goList(path, srcDir)
+	if err != nil {
+		return "", "", err
+	}
+	imp.dirs[path] = [2]string{dir, importPath}
+	return dir, importPath, nil
+}
+
+// goList 在 srcDir 目录中离线运行 go list 定位非标准库的包
+func (imp *sourceImporter) goList(path, srcDir string) (dir, importPath string, err error) {
+	goCmd := "go"
+	if imp.ctx.GOROOT != "" {
+		goCmd = filepath.Join(imp.ctx.GOROOT, "bin", "go")
+	}
+	cmd := exec.Command(goCmd, "list", "-e", "-f", "{{.Dir}}\n{{.ImportPath}}\n{{if .Error}}{{.Error}}{{end}}", "--", path)
+	cmd.Dir = srcDir
+	cmd.Env = append(os.Environ(), "GOPROXY=off", "GOTOOLCHAIN=local", "CGO_ENABLED=0")
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", "", localModuleError(path, strings.TrimSpace(stderr.String()))
+	}
+
+	f := strings.SplitN(string(out), "\n", 3)
+	if len(f) != 3 || f[0] == "" {
+		msg := ""
+		if len(f) == 3 {
+			msg = strings.TrimSpace(f[2])
+		}
+		return "", "", localModuleError(path, msg)
+	}
+	return f[0], f[1], nil
+}
+
+// localModuleError 把 go list 的错误转换为类型检查的错误，包所在的模块不在本地时给出明确的提示
+func localModuleError(path, msg string) error {
+	if strings.Contains(msg, "GOPROXY=off") || strings.Contains(msg, "go.sum") || strings.Contains(msg, "go mod download") {
+		return fmt.Errorf("%s 所在的模块在本地不可用，类型检查不会下载模块，请先运行 go mod download: %s", path, msg)
+	}
+	return fmt.Errorf("找不到包 %s: %s", path, msg)
+}
@@ -0,0 +1,89 @@
+package gox
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// typeCheckModule 返回通过 replace 引用当前仓库的临时模块的 go.mod
+func typeCheckModule(t *testing.T, extra string) string {
+	t.Helper()
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go command not found")
+	}
+	repo, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return "module tctest\n\ngo 1.24\n\nrequire github.com/llyb120/gox v0.0.0\n" + extra +
+		"\nreplace github.com/llyb120/gox => " + filepath.ToSlash(repo) + "\n"
+}
+
+func TestTypeCheckPositions(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod": typeCheckModule(t, ""),
+		"dao/a.gox.go": "//go:build ignore\n" +
+			"\n" +
+			"package dao\n" +
+			"\n" +
+			"import \"github.com/llyb120/gox\"\n" +
+			"\n" +
+			"func Find(id int) gox.Query {\n" +
+			"\treturn gox.Sql(`\n" +
+			"\t\tselect * from t where a = #{missA}\n" +
+			"\t\t{\n" +
+			"\t\t\tif id > 0 {\n" +
+			"\t\t\t\t@and b = #{missB}\n" +
+			"\t\t\t}\n" +
+			"\t\t}\n" +
+			"\t\t@{ and c = #{missC} }\n" +
+			"\t`)\n" +
+			"}\n",
+		"dao/ok.go": "package dao\n",
+	})
+
+	c := newTestCompiler(filepath.Join(dir, "dao"))
+	c.Layout = LayoutAlongside
+	c.TypeCheck = true
+	err := c.Compile()
+
+	var list TypeErrors
+	if !errors.As(err, &list) {
+		t.Fatalf("Compile error %v is not TypeErrors", err)
+	}
+	var got []string
+	for _, e := range list {
+		got = append(got, fmt.Sprintf("%s:%d:%d: %s", filepath.Base(e.Pos.Filename), e.Pos.Line, e.Pos.Column, e.Msg))
+	}
+	want := []string{
+		"a.gox.go:9:31: undefined: missA",
+		"a.gox.go:12:16: undefined: missB",
+		"a.gox.go:15:16: undefined: missC",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("type errors:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestTypeCheckOffline(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod": typeCheckModule(t, "require example.com/missing v1.0.0\n"),
+		"dao/a.gox.go": "//go:build ignore\n\npackage dao\n\nimport (\n\t\"example.com/missing\"\n\t\"github.com/llyb120/gox\"\n)\n\n" +
+			"func Find() gox.Query {\n\treturn gox.Sql(`select #{missing.ID}`)\n}\n",
+	})
+
+	c := newTestCompiler(filepath.Join(dir, "dao"))
+	c.Layout = LayoutAlongside
+	c.TypeCheck = true
+	err := c.Compile()
+	if err == nil || !strings.Contains(err.Error(), "example.com/missing 所在的模块在本地不可用") {
+		t.Errorf("Compile error = %v, want module not available locally", err)
+	}
+}
@@ -0,0 +1,4 @@
+package gox
+
+// Version gox 版本号，生成代码的格式发生变化时需要同步更新
+const Version = "0.2.0"
@@ -0,0 +1,211 @@
+package gox
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+// 监听模式的默认参数
+const (
+	defaultWatchInterval = 500 * time.Millisecond
+	defaultWatchDebounce = 300 * time.Millisecond
+)
+
+// Watch 监听模式：轮询 SrcPath 下的 .gox.go 文件，只重新编译发生变化的文件
+// 启动时会先编译全部文件；源文件被删除时同步删除对应的 _gen.go 文件（不是由 gox 生成的同名文件会保留）；
+// 一段时间内的连续保存会被合并为一次编译（防抖）
+func (c *Compiler) Watch() error {
+	return c.WatchContext(context.Background())
+}
+
+// WatchContext 与 Watch 相同，ctx 取消时中断正在进行的编译，保存清单后返回 ctx.Err()
+func (c *Compiler) WatchContext(ctx context.Context) error {
+	if err := c.prepare(); err != nil {
+		return err
+	}
+
+	path := c.SrcPath
+	if c.SingleFile != "" {
+		path = c.SingleFile
+	}
+
+	interval := c.WatchInterval
+	if interval <= 0 {
+		interval = defaultWatchInterval
+	}
+	debounce := c.WatchDebounce
+	if debounce <= 0 {
+		debounce = defaultWatchDebounce
+	}
+
+	if c.Manifest {
+		m, err := loadManifest(c.outputRoot(), c.manifestOptions())
+		if err != nil {
+			return err
+		}
+		c.manifest = m
+	}
+	if c.overlayMode() {
+		o, err := loadOverlay(c.Overlay)
+		if err != nil {
+			return err
+		}
+		c.overlay = o
+	}
+
+	rep := c.reporter()
+
+	// 初始快照为空，第一次轮询时所有文件都会被视为变化，从而完成一次完整编译
+	snapshot := map[string]time.Time{}
+	pending := map[string]bool{}
+	var lastChange time.Time
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		current, err := c.scanGoxFiles(ctx, path)
+		if ctx.Err() != nil {
+			return c.stopWatch(ctx)
+		}
+		if err != nil {
+			rep.FileFailed(path, fmt.Errorf("扫描目录失败: %w", err))
+		} else {
+			// 新增或修改的文件
+			for file, modTime := range current {
+				if old, ok := snapshot[file]; !ok || !old.Equal(modTime) {
+					pending[file] = true
+					lastChange = time.Now()
+				}
+			}
+			// 被删除的文件
+			for file := range snapshot {
+				if _, ok := current[file]; !ok {
+					pending[file] = true
+					lastChange = time.Now()
+				}
+			}
+			snapshot = current
+		}
+
+		if len(pending) > 0 && time.Since(lastChange) >= debounce {
+			c.rebuildChanged(ctx, pending, snapshot)
+			pending = map[string]bool{}
+			c.saveWatchState()
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return c.stopWatch(ctx)
+		}
+	}
+}
+
+// saveWatchState 保存监听模式下更新的清单和 overlay 文件，失败时通过 Reporter 报告
+func (c *Compiler) saveWatchState() {
+	rep := c.reporter()
+	if c.manifest != nil {
+		if err := c.manifest.save(); err != nil {
+			rep.FileFailed(c.manifest.path, err)
+		}
+	}
+	if c.overlay != nil {
+		if err := c.overlay.save(); err != nil {
+			rep.FileFailed(c.overlay.path, err)
+		}
+	}
+}
+
+// stopWatch 监听被取消时保存已完成文件的记录，返回 ctx.Err()
+func (c *Compiler) stopWatch(ctx context.Context) error {
+	c.saveWatchState()
+	return ctx.Err()
+}
+
+// scanGoxFiles 返回路径下所有 .gox.go 文件及其修改时间
+func (c *Compiler) scanGoxFiles(ctx context.Context, path string) (map[string]time.Time, error) {
+	files, err := c.collectGoxFiles(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]time.Time, len(files))
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			// 文件在扫描期间被删除，下一轮再处理
+			continue
+		}
+		result[file] = info.ModTime()
+	}
+	return result, nil
+}
+
+// rebuildChanged 重新编译发生变化的文件，已删除的源文件会移除对应的目标文件；ctx 取消时不再处理剩余的文件
+func (c *Compiler) rebuildChanged(ctx context.Context, changed map[string]bool, existing map[string]time.Time) {
+	files := make([]string, 0, len(changed))
+	for file := range changed {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	// 与其他源文件输出冲突的文件不编译
+	all := make([]string, 0, len(existing))
+	for file := range existing {
+		all = append(all, file)
+	}
+	conflicts := make(map[string]error)
+	for _, fe := range c.outputConflicts(all) {
+		conflicts[fe.File] = fe.Err
+	}
+
+	rep := c.reporter()
+	var results []*fileResult
+	for _, file := range files {
+		if ctx.Err() != nil {
+			break
+		}
+		if err, ok := conflicts[file]; ok {
+			results = append(results, &fileResult{Source: file, Status: fileFailed, Err: err})
+			continue
+		}
+		if _, ok := existing[file]; ok {
+			rep.FileStart(file)
+			results = append(results, c.processGoxFile(file, c.Incremental, c.DebugMode))
+			continue
+		}
+
+		// 与写入时相同，不删除不是由 gox 生成的同名文件，除非设置了 Force；需要在清单遗忘该文件之前判断
+		goPath := c.outputPath(file)
+		owned := c.Force || c.ownsOutput(goPath)
+		if c.manifest != nil {
+			c.manifest.forget(file)
+		}
+		if c.overlay != nil {
+			c.overlay.forget(file)
+		}
+		if !owned {
+			continue
+		}
+		if err := os.Remove(goPath); err != nil {
+			if !os.IsNotExist(err) {
+				rep.FileFailed(goPath, fmt.Errorf("移除文件失败: %w", err))
+			}
+			continue
+		}
+		rep.FileRemoved(goPath)
+	}
+
+	if c.TypeCheck {
+		c.typeCheck(results)
+	}
+
+	// 失败信息已经通过 Reporter 输出，监听模式下继续运行
+	if len(results) > 0 {
+		_ = c.reportResults(results)
+	}
+}
@@ -0,0 +1,52 @@
+package gox
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// writeFileAtomic 通过临时文件加重命名的方式写入文件，避免中断时留下不完整的文件
+// 新内容与现有文件相同时不写入，返回 changed=false，保留原有的修改时间
+func writeFileAtomic(path string, data []byte, perm os.FileMode) (changed bool, err error) {
+	if existing, err := os.ReadFile(path); err == nil && bytes.Equal(existing, data) {
+		return false, nil
+	}
+
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return false, fmt.Errorf("创建目录失败 %s: %v", dir, err)
+	}
+
+	// 临时文件与目标文件在同一目录，保证 rename 是原子操作
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return false, err
+	}
+	tmpName := tmp.Name()
+	defer func() {
+		if err != nil {
+			os.Remove(tmpName)
+		}
+	}()
+
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		return false, err
+	}
+	if err = tmp.Sync(); err != nil {
+		tmp.Close()
+		return false, err
+	}
+	if err = tmp.Close(); err != nil {
+		return false, err
+	}
+	if err = os.Chmod(tmpName, perm); err != nil {
+		return false, err
+	}
+	if err = os.Rename(tmpName, path); err != nil {
+		return false, err
+	}
+	return true, nil
+}